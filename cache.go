@@ -0,0 +1,110 @@
+package tmi
+
+import (
+	"strings"
+	"sync"
+)
+
+// MessageCache remembers the most recent PRIVMSGs in each channel, so that
+// the messages removed by a CLEARMSG or CLEARCHAT can be looked up.
+// Register it with Track, or feed it events with Handle.
+type MessageCache struct {
+	size     int
+	mu       sync.Mutex
+	channels map[string]*ring
+}
+
+// ring is a fixed size buffer of a channel's messages.
+type ring struct {
+	messages []PRIVMSG
+	next     int
+	ids      map[string]int // message ID to index in messages
+}
+
+// NewMessageCache creates a cache holding up to size messages per channel.
+func NewMessageCache(size int) *MessageCache {
+	return &MessageCache{size: size, channels: make(map[string]*ring)}
+}
+
+// Handle records PRIVMSG events.
+func (m *MessageCache) Handle(event Event) {
+	msg, ok := event.(PRIVMSG)
+	if !ok || m.size <= 0 {
+		return
+	}
+	id, _ := msg.ID()
+	channel := msg.Channel()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.channels[channel]
+	if !ok {
+		r = &ring{ids: make(map[string]int)}
+		m.channels[channel] = r
+	}
+	if len(r.messages) < m.size {
+		r.messages = append(r.messages, msg)
+	} else {
+		old := &r.messages[r.next]
+		if oldID, _ := old.ID(); r.ids[oldID] == r.next {
+			delete(r.ids, oldID)
+		}
+		r.messages[r.next] = msg
+	}
+	if id != "" {
+		r.ids[id] = r.next
+	}
+	r.next = (r.next + 1) % m.size
+}
+
+// Message returns the message with the given ID in a channel.
+func (m *MessageCache) Message(channel, id string) (PRIVMSG, bool) {
+	channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.channels[channel]
+	if !ok {
+		return PRIVMSG{}, false
+	}
+	i, ok := r.ids[id]
+	if !ok {
+		return PRIVMSG{}, false
+	}
+	return r.messages[i], true
+}
+
+// Messages returns the cached messages in a channel, oldest first. If login
+// is not empty, only that user's messages are returned.
+func (m *MessageCache) Messages(channel, login string) []PRIVMSG {
+	channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	r, ok := m.channels[channel]
+	if !ok {
+		return nil
+	}
+	var messages []PRIVMSG
+	for i := range r.messages {
+		// When the ring is full, r.next is the oldest message.
+		msg := r.messages[(r.next+i)%len(r.messages)]
+		if login == "" || strings.EqualFold(msg.Author(), login) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages
+}
+
+// Deleted returns the message removed by a CLEARMSG.
+func (m *MessageCache) Deleted(ev CLEARMSG) (PRIVMSG, bool) {
+	id, err := ev.TargetMsgID()
+	if err != nil || id == "" {
+		return PRIVMSG{}, false
+	}
+	return m.Message(ev.Channel(), id)
+}
+
+// Cleared returns the messages removed by a CLEARCHAT: those of the banned
+// or timed out user, or every message if the whole chat was cleared.
+func (m *MessageCache) Cleared(ev CLEARCHAT) []PRIVMSG {
+	return m.Messages(ev.Channel(), ev.Nick())
+}
@@ -0,0 +1,45 @@
+package tmi
+
+import "testing"
+
+func TestMessageCache(t *testing.T) {
+	m := NewMessageCache(3)
+	handle := func(line string) {
+		m.Handle(toevent(mustParse(t, line)))
+	}
+	handle("@id=1 :foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :one")
+	handle("@id=2 :baz!baz@baz.tmi.twitch.tv PRIVMSG #bar :two")
+	handle("@id=3 :foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :three")
+	handle("@id=4 :foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :four")
+	handle("@id=5 :foo!foo@foo.tmi.twitch.tv PRIVMSG #other :five")
+
+	if _, ok := m.Message("bar", "1"); ok {
+		t.Error("Message(1) found, want evicted")
+	}
+
+	clearmsg := toevent(mustParse(t, "@login=foo;target-msg-id=3 :tmi.twitch.tv CLEARMSG #bar :three")).(CLEARMSG)
+	if msg, ok := m.Deleted(clearmsg); !ok || msg.Message() != "three" {
+		t.Errorf("Deleted() = %q, %v", msg.Message(), ok)
+	}
+
+	clearchat := toevent(mustParse(t, "@ban-duration=600 :tmi.twitch.tv CLEARCHAT #bar :foo")).(CLEARCHAT)
+	if got := texts(m.Cleared(clearchat)); got != "three,four" {
+		t.Errorf("Cleared(timeout) = %s", got)
+	}
+
+	clearchat = toevent(mustParse(t, ":tmi.twitch.tv CLEARCHAT #bar")).(CLEARCHAT)
+	if got := texts(m.Cleared(clearchat)); got != "two,three,four" {
+		t.Errorf("Cleared(clear) = %s", got)
+	}
+}
+
+func texts(messages []PRIVMSG) string {
+	var s string
+	for i, msg := range messages {
+		if i > 0 {
+			s += ","
+		}
+		s += msg.Message()
+	}
+	return s
+}
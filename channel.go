@@ -0,0 +1,275 @@
+package tmi
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ChannelState is a snapshot of a joined channel's room settings, merged
+// from ROOMSTATE, and the client's own state in it, from USERSTATE.
+type ChannelState struct {
+	Name   string
+	RoomID string
+
+	EmoteOnly     bool
+	FollowersOnly time.Duration // Minimum follow age, negative when disabled.
+	R9K           bool
+	Slow          time.Duration // Minimum time between messages, 0 when disabled.
+	SubsOnly      bool
+
+	Badges      map[string]string // Badge name to version, e.g. "subscriber": "12".
+	Mod         bool
+	Color       string
+	DisplayName string
+}
+
+// ChannelChange is emitted after a ROOMSTATE or USERSTATE changed the
+// state of a channel.
+type ChannelChange struct {
+	Old, New ChannelState
+}
+
+// duplicateWindow is how long Twitch remembers a message when checking for
+// duplicates.
+const duplicateWindow = 30 * time.Second
+
+// duplicateSuffix is appended to a message identical to the previous one.
+// U+E0000 is invisible in chat, and the space keeps it from sticking to
+// the last word.
+const duplicateSuffix = " \U000E0000"
+
+// channelState is what the client knows about a joined channel.
+type channelState struct {
+	ChannelState
+
+	// Twitch doesn't tell us whether we follow a channel, so followers-only
+	// mode is only enforced once the server rejected one of our messages.
+	notFollowing bool
+}
+
+// sentMessage is the last message the client wrote to a channel, joined
+// or not.
+type sentMessage struct {
+	text string
+	time time.Time
+}
+
+// RestrictionError is reported when a message is not sent because the
+// channel's settings would make the server reject it.
+type RestrictionError struct {
+	Channel string
+	Mode    string        // "slow", "subs-only" or "followers-only".
+	Wait    time.Duration // How long until slow mode allows a message, 0 if it never allows the command.
+}
+
+func (e *RestrictionError) Error() string {
+	if e.Wait > 0 {
+		return fmt.Sprintf("tmi: #%s is in %s mode, wait %v", e.Channel, e.Mode, e.Wait)
+	}
+	return fmt.Sprintf("tmi: #%s is in %s mode", e.Channel, e.Mode)
+}
+
+// Exempt reports whether the client's badges exempt it from slow,
+// followers-only and subs-only mode.
+func (s ChannelState) Exempt() bool {
+	if s.Mod {
+		return true
+	}
+	for _, badge := range []string{"broadcaster", "moderator", "vip", "staff", "admin", "global_mod"} {
+		if _, ok := s.Badges[badge]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Subscriber reports whether the client is subscribed to the channel.
+func (s ChannelState) Subscriber() bool {
+	_, sub := s.Badges["subscriber"]
+	_, founder := s.Badges["founder"]
+	return sub || founder
+}
+
+// channel returns the state of channel, creating it if necessary.
+// c.mu must be held.
+func (c *Client) channel(name string) *channelState {
+	if c.channels == nil {
+		c.channels = make(map[string]*channelState)
+	}
+	ch, ok := c.channels[name]
+	if !ok {
+		ch = &channelState{ChannelState: ChannelState{Name: name, FollowersOnly: -1}}
+		c.channels[name] = ch
+	}
+	return ch
+}
+
+// restriction returns a *RestrictionError if n messages to channel would be
+// rejected by the server because of the channel's settings.
+func (c *Client) restriction(channel string, n int) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch, ok := c.channels[channel]
+	if !ok || ch.Exempt() {
+		return nil
+	}
+	if ch.SubsOnly && !ch.Subscriber() {
+		return &RestrictionError{Channel: channel, Mode: "subs-only"}
+	}
+	if ch.FollowersOnly >= 0 && ch.notFollowing {
+		return &RestrictionError{Channel: channel, Mode: "followers-only"}
+	}
+	if ch.Slow > 0 && n > 1 {
+		// Slow mode would reject all but the first, whenever it is sent.
+		return &RestrictionError{Channel: channel, Mode: "slow"}
+	}
+	if wait := ch.Slow - time.Since(c.lastSent[channel].time); ch.Slow > 0 && wait > 0 {
+		return &RestrictionError{Channel: channel, Mode: "slow", Wait: wait}
+	}
+	return nil
+}
+
+// unique rewrites an outgoing PRIVMSG if it would be dropped as a
+// duplicate of the previous message to its channel, which is the one in
+// pending if the same command already has one for it. pending is updated
+// with p, and unique reports whether p was modified.
+func (c *Client) unique(p *Packet, pending map[string]string) bool {
+	channel := p.Params[0][1:]
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	previous, ok := pending[channel]
+	if last := c.lastSent[channel]; !ok && time.Since(last.time) < duplicateWindow {
+		previous = last.text
+	}
+	modified := false
+	if c.bypassDuplicates && p.Params[1] == previous {
+		p.Params[1] += duplicateSuffix
+		modified = true
+	}
+	pending[channel] = p.Params[1]
+	return modified
+}
+
+// sent records the last message written to each channel.
+func (c *Client) sent(messages map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.lastSent == nil {
+		c.lastSent = make(map[string]sentMessage)
+	}
+	now := time.Now()
+	for channel, message := range messages {
+		c.lastSent[channel] = sentMessage{message, now}
+	}
+}
+
+// Channel returns a snapshot of the state of a joined channel.
+func (c *Client) Channel(name string) (ChannelState, bool) {
+	name = strings.ToLower(strings.TrimPrefix(name, "#"))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ch, ok := c.channels[name]
+	if !ok {
+		return ChannelState{}, false
+	}
+	return ch.snapshot(), true
+}
+
+func (s ChannelState) snapshot() ChannelState {
+	badges := make(map[string]string, len(s.Badges))
+	for name, version := range s.Badges {
+		badges[name] = version
+	}
+	s.Badges = badges
+	return s
+}
+
+// track updates the channel state with an incoming packet, returning the
+// change if there was one.
+func (c *Client) track(p Packet) *ChannelChange {
+	if len(p.Params) == 0 || !strings.HasPrefix(p.Params[0], "#") {
+		return nil
+	}
+	name := p.Params[0][1:]
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch p.Command {
+	case "NOTICE":
+		if ch, ok := c.channels[name]; ok && strings.HasPrefix(p.Tags["msg-id"], "msg_followersonly") {
+			ch.notFollowing = true
+		}
+		return nil
+	case "PART":
+		if strings.EqualFold(p.Prefix.Nick, c.nick) {
+			delete(c.channels, name)
+		}
+		return nil
+	case "ROOMSTATE", "USERSTATE":
+	default:
+		return nil
+	}
+
+	ch := c.channel(name)
+	old := ch.snapshot()
+	if p.Command == "ROOMSTATE" {
+		// ROOMSTATE updates only carry the tags that changed.
+		for tag, value := range p.Tags {
+			switch tag {
+			case "room-id":
+				ch.RoomID = value
+			case "emote-only":
+				ch.EmoteOnly = value == "1"
+			case "followers-only":
+				if n, err := strconv.Atoi(value); err == nil {
+					ch.FollowersOnly = time.Duration(n) * time.Minute
+					if n < 0 {
+						ch.FollowersOnly = -1
+					}
+					ch.notFollowing = false
+				}
+			case "r9k":
+				ch.R9K = value == "1"
+			case "slow":
+				if n, err := strconv.Atoi(value); err == nil {
+					ch.Slow = time.Duration(n) * time.Second
+				}
+			case "subs-only":
+				ch.SubsOnly = value == "1"
+			}
+		}
+	} else if p.Tags != nil {
+		ch.Badges = parseBadges(p.Tags["badges"])
+		ch.Mod = p.Tags["mod"] == "1"
+		ch.Color = p.Tags["color"]
+		ch.DisplayName = p.Tags["display-name"]
+	}
+	change := ChannelChange{Old: old, New: ch.snapshot()}
+	if reflect.DeepEqual(change.Old, change.New) {
+		return nil
+	}
+	return &change
+}
+
+// parseBadges parses a badges tag such as "moderator/1,subscriber/12".
+func parseBadges(tag string) map[string]string {
+	badges := make(map[string]string)
+	for _, badge := range strings.Split(tag, ",") {
+		if badge == "" {
+			continue
+		}
+		split := strings.SplitN(badge, "/", 2)
+		if len(split) == 2 {
+			badges[split[0]] = split[1]
+		} else {
+			badges[split[0]] = ""
+		}
+	}
+	return badges
+}
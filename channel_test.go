@@ -0,0 +1,115 @@
+package tmi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, line string) Packet {
+	t.Helper()
+	p, err := parsePacket([]byte(line))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestChannelState(t *testing.T) {
+	c, _ := NewClient(Auth("bot", "oauth:x"))
+
+	change := c.track(mustParse(t, "@emote-only=0;followers-only=-1;r9k=0;rituals=0;room-id=12345678;slow=0;subs-only=0 :tmi.twitch.tv ROOMSTATE #bar"))
+	if change == nil || change.New.RoomID != "12345678" || change.New.FollowersOnly != -1 {
+		t.Fatalf("initial ROOMSTATE change = %+v", change)
+	}
+	if change := c.track(mustParse(t, "@emote-only=0;followers-only=-1;r9k=0;rituals=0;room-id=12345678;slow=0;subs-only=0 :tmi.twitch.tv ROOMSTATE #bar")); change != nil {
+		t.Errorf("repeated ROOMSTATE change = %+v, want none", change)
+	}
+
+	change = c.track(mustParse(t, "@room-id=12345678;slow=30 :tmi.twitch.tv ROOMSTATE #bar"))
+	if change == nil || change.Old.Slow != 0 || change.New.Slow != 30*time.Second {
+		t.Fatalf("slow ROOMSTATE change = %+v", change)
+	}
+
+	change = c.track(mustParse(t, "@badge-info=;badges=moderator/1;color=#0D4200;display-name=Bot;emote-sets=0;mod=1;subscriber=0;user-type=mod :tmi.twitch.tv USERSTATE #bar"))
+	if change == nil || !change.New.Mod || change.New.Badges["moderator"] != "1" {
+		t.Fatalf("USERSTATE change = %+v", change)
+	}
+
+	state, ok := c.Channel("#Bar")
+	if !ok {
+		t.Fatal("Channel() not found")
+	}
+	if state.Slow != 30*time.Second || state.RoomID != "12345678" || state.Color != "#0D4200" || state.DisplayName != "Bot" {
+		t.Errorf("Channel() = %+v", state)
+	}
+	state.Badges["vip"] = "1"
+	if state, _ := c.Channel("bar"); state.Badges["vip"] != "" {
+		t.Error("Channel() snapshot shares badges with the client")
+	}
+
+	c.track(mustParse(t, ":bot!bot@bot.tmi.twitch.tv PART #bar"))
+	if _, ok := c.Channel("bar"); ok {
+		t.Error("Channel() found after PART")
+	}
+}
+
+func TestRestrictions(t *testing.T) {
+	c, _ := NewClient(Auth("bot", "oauth:x"))
+	_, r := pipeClient(t, c)
+	go func() {
+		for {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+		}
+	}()
+	c.track(mustParse(t, "@emote-only=0;followers-only=-1;r9k=0;room-id=1;slow=1;subs-only=0 :tmi.twitch.tv ROOMSTATE #bar"))
+	c.track(mustParse(t, "@badges=;mod=0 :tmi.twitch.tv USERSTATE #bar"))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := c.Send(ctx, Say("bar", "one")); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	res := c.Submit(Say("bar", "two"))
+	var restriction *RestrictionError
+	if err := res.Wait(ctx); !errors.As(err, &restriction) || restriction.Mode != "slow" || restriction.Wait <= 0 || res.Status() != Restricted {
+		t.Fatalf("Submit() = %v, %v; want slow mode", res.Status(), err)
+	}
+	start := time.Now()
+	if err := c.Send(ctx, Say("bar", "three")); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	if waited := time.Since(start); waited < restriction.Wait/2 {
+		t.Errorf("Send() returned after %v, want it delayed by slow mode", waited)
+	}
+	both := func(w io.Writer) error {
+		if err := Say("bar", "one")(w); err != nil {
+			return err
+		}
+		return Say("bar", "two")(w)
+	}
+	if err := c.Send(ctx, both); !errors.As(err, &restriction) || restriction.Mode != "slow" || restriction.Wait != 0 {
+		t.Errorf("Send() of two messages = %v, want slow mode without wait", err)
+	}
+
+	c.track(mustParse(t, "@room-id=1;subs-only=1 :tmi.twitch.tv ROOMSTATE #bar"))
+	if err := c.Send(ctx, Say("bar", "four")); !errors.As(err, &restriction) || restriction.Mode != "subs-only" {
+		t.Errorf("Send() = %v, want subs-only", err)
+	}
+	c.track(mustParse(t, "@badges=moderator/1;mod=1 :tmi.twitch.tv USERSTATE #bar"))
+	if err := c.Send(ctx, Say("bar", "five")); err != nil {
+		t.Errorf("Send() as moderator = %v", err)
+	}
+
+	c.track(mustParse(t, "@room-id=1;subs-only=0;followers-only=10 :tmi.twitch.tv ROOMSTATE #bar"))
+	c.track(mustParse(t, "@badges=;mod=0 :tmi.twitch.tv USERSTATE #bar"))
+	c.track(mustParse(t, "@msg-id=msg_followersonly :tmi.twitch.tv NOTICE #bar :This room is in 10 minutes followers-only mode."))
+	if err := c.Send(ctx, Say("bar", "six")); !errors.As(err, &restriction) || restriction.Mode != "followers-only" {
+		t.Errorf("Send() = %v, want followers-only", err)
+	}
+}
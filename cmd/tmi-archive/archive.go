@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+const (
+	dayLayout = "2006-01-02"
+	// window within which an event received twice, from two connections or
+	// across a restart, is recognized as a duplicate.
+	window = 5 * time.Minute
+)
+
+// record is a line of the archive.
+type record struct {
+	Time    time.Time         `json:"time"`
+	Channel string            `json:"channel"`
+	Command string            `json:"command"`
+	Nick    string            `json:"nick,omitempty"`
+	Params  []string          `json:"params,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+
+	conn int // index of the connection it was received on
+}
+
+// newRecord makes the record of an event received at now, false for events
+// that are not archived.
+func newRecord(ev tmi.Event, now time.Time) (record, bool) {
+	p, ok := tmi.PacketOf(ev)
+	if !ok {
+		return record{}, false
+	}
+	params := p.Params
+	switch p.Command {
+	case "353", "366", "USERSTATE", "GLOBALUSERSTATE":
+		// About the archive's own connections.
+		return record{}, false
+	case "JOIN", "PART":
+		if strings.HasPrefix(p.Prefix.Nick, "justinfan") {
+			return record{}, false
+		}
+	}
+	if len(params) == 0 || !strings.HasPrefix(params[0], "#") {
+		return record{}, false
+	}
+	r := record{
+		Time:    now.UTC(),
+		Channel: params[0][1:],
+		Command: p.Command,
+		Nick:    p.Prefix.Nick,
+		Params:  params[1:],
+	}
+	if p.Tags != nil {
+		r.Tags = make(map[string]string, len(p.Tags))
+		for key, value := range p.Tags {
+			r.Tags[key] = tmi.UnescapeTag(value)
+		}
+		if ms, err := strconv.ParseInt(p.Tags["tmi-sent-ts"], 10, 64); err == nil {
+			r.Time = time.Unix(0, ms*int64(time.Millisecond)).UTC()
+		}
+	}
+	return r, true
+}
+
+// key identifies a record regardless of the connection it was received on.
+// It is not unique for events without an id or tmi-sent-ts tag, such as a
+// user joining again, which are told apart by counting them instead.
+func (r record) key() (key string, unique bool) {
+	if id := r.Tags["id"]; id != "" {
+		return id, true
+	}
+	key = fmt.Sprint(r.Channel, r.Command, r.Nick, r.Params, r.Tags)
+	return key, r.Tags["tmi-sent-ts"] != ""
+}
+
+// archive writes records to dir/<channel>/<day>.jsonl. Days are gzipped to
+// <day>.jsonl.gz once a later one starts; events arriving late for them are
+// appended as another gzip member.
+type archive struct {
+	dir    string
+	files  map[string]*dayFile // by channel
+	seen   map[string]time.Time
+	counts map[string]*count // of records without a unique key
+	now    func() time.Time
+}
+
+// count is how often an event without a unique key occurred within the
+// window: its nth occurrence on a connection is a duplicate if another
+// connection already had n.
+type count struct {
+	written int
+	conns   map[int]int
+	last    time.Time
+}
+
+type dayFile struct {
+	day string
+	f   *os.File
+}
+
+// openArchive opens the archive in dir, compressing the days left over by
+// a previous run and remembering today's records to skip duplicates.
+func openArchive(dir string, now func() time.Time) (*archive, error) {
+	a := &archive{
+		dir:    dir,
+		files:  make(map[string]*dayFile),
+		seen:   make(map[string]time.Time),
+		counts: make(map[string]*count),
+		now:    now,
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "*", "*.jsonl"))
+	if err != nil {
+		return nil, err
+	}
+	today := a.now().UTC().Format(dayLayout)
+	for _, path := range paths {
+		day := strings.TrimSuffix(filepath.Base(path), ".jsonl")
+		if day < today {
+			if err := compress(path); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := a.remember(path); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// remember the records of a file, truncating a line cut short by a crash.
+// Records without a unique key are not remembered, as the connections of
+// this run count them afresh.
+func (a *archive) remember(path string) error {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if i := bytes.LastIndexByte(b, '\n'); i < len(b)-1 {
+		b = b[:i+1]
+		if err := os.Truncate(path, int64(len(b))); err != nil {
+			return err
+		}
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var r record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		if key, unique := r.key(); unique {
+			a.seen[key] = a.now()
+		}
+	}
+	return scanner.Err()
+}
+
+// duplicate reports whether r was already written within the window.
+func (a *archive) duplicate(r record) bool {
+	now := a.now()
+	key, unique := r.key()
+	if !unique {
+		return a.repeated(key, r.conn, now)
+	}
+	if t, ok := a.seen[key]; ok && now.Sub(t) < window {
+		return true
+	}
+	a.seen[key] = now
+	if len(a.seen)%1024 == 0 {
+		for key, t := range a.seen {
+			if now.Sub(t) >= window {
+				delete(a.seen, key)
+			}
+		}
+	}
+	return false
+}
+
+// repeated counts an occurrence of a record without a unique key on its
+// connection, and reports whether another connection already had as many.
+func (a *archive) repeated(key string, conn int, now time.Time) bool {
+	c, ok := a.counts[key]
+	if !ok || now.Sub(c.last) >= window {
+		c = &count{conns: make(map[int]int)}
+		a.counts[key] = c
+	}
+	c.last = now
+	c.conns[conn]++
+	if c.conns[conn] <= c.written {
+		return true
+	}
+	c.written = c.conns[conn]
+	if len(a.counts)%1024 == 0 {
+		for key, c := range a.counts {
+			if now.Sub(c.last) >= window {
+				delete(a.counts, key)
+			}
+		}
+	}
+	return false
+}
+
+// write a record unless it is a duplicate.
+func (a *archive) write(r record) error {
+	if a.duplicate(r) {
+		return nil
+	}
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	day := r.Time.Format(dayLayout)
+	path := filepath.Join(a.dir, r.Channel, day+".jsonl")
+	current := a.files[r.Channel]
+	if current != nil && current.day == day {
+		_, err := current.f.Write(line)
+		return err
+	}
+	if _, err := os.Stat(path + ".gz"); err == nil || (current != nil && day < current.day) {
+		return appendGzip(path+".gz", line)
+	}
+	if current != nil {
+		if err := a.rotate(r.Channel); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	a.files[r.Channel] = &dayFile{day, f}
+	_, err = f.Write(line)
+	return err
+}
+
+// rotate closes and compresses the open file of a channel.
+func (a *archive) rotate(channel string) error {
+	current := a.files[channel]
+	delete(a.files, channel)
+	if err := current.f.Close(); err != nil {
+		return err
+	}
+	return compress(current.f.Name())
+}
+
+// Close the open files, leaving them to be compressed by a later run once
+// their day is over.
+func (a *archive) Close() error {
+	var err error
+	for channel, current := range a.files {
+		if e := current.f.Close(); e != nil && err == nil {
+			err = e
+		}
+		delete(a.files, channel)
+	}
+	return err
+}
+
+// compress path to path.gz and remove it. If path.gz exists, a previous
+// run was interrupted after compressing path, and path is only removed.
+func compress(path string) error {
+	if _, err := os.Stat(path + ".gz"); err == nil {
+		return os.Remove(path)
+	}
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := path + ".gz.tmp"
+	dst, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if e := zw.Close(); err == nil {
+		err = e
+	}
+	if e := dst.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// appendGzip appends line to a gzip file as a new member.
+func appendGzip(path string, line []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(f)
+	_, err = zw.Write(line)
+	if e := zw.Close(); err == nil {
+		err = e
+	}
+	if e := f.Close(); err == nil {
+		err = e
+	}
+	return err
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+func privmsg(t *testing.T, id string, sent time.Time) record {
+	t.Helper()
+	ms := sent.UnixNano() / int64(time.Millisecond)
+	ev := tmi.PRIVMSG{
+		Tags:    map[string]string{"id": id, "display-name": `A\sB`, "tmi-sent-ts": strconv.FormatInt(ms, 10)},
+		Command: "PRIVMSG",
+		Params:  []string{"#nymn", "hi " + id},
+	}
+	ev.Prefix.Nick = "a"
+	r, ok := newRecord(ev, time.Now())
+	if !ok {
+		t.Fatal("PRIVMSG not archived")
+	}
+	return r
+}
+
+func TestArchive(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmi-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	day1 := time.Date(2022, 1, 20, 23, 59, 0, 0, time.UTC)
+	day2 := day1.Add(2 * time.Minute)
+	now := func() time.Time { return day1 }
+	a, err := openArchive(dir, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, r := range []record{
+		privmsg(t, "1", day1),
+		privmsg(t, "1", day1), // from the other connection
+		privmsg(t, "2", day2),
+		privmsg(t, "3", day1), // late
+		privmsg(t, "4", day2),
+	} {
+		if err := a.write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if r := privmsg(t, "1", day1); r.Tags["display-name"] != "A B" {
+		t.Errorf("tag not decoded: %q", r.Tags["display-name"])
+	}
+
+	if got := ids(t, gunzip(t, filepath.Join(dir, "nymn", "2022-01-20.jsonl.gz"))); got != "1 3" {
+		t.Errorf("2022-01-20 has messages %q, want 1 3", got)
+	}
+	today := filepath.Join(dir, "nymn", "2022-01-21.jsonl")
+	if got := ids(t, read(t, today)); got != "2 4" {
+		t.Errorf("2022-01-21 has messages %q, want 2 4", got)
+	}
+	a.Close()
+
+	// A crash cut the last line short; a restart on the same day resumes
+	// the file and skips what it already has.
+	f, _ := os.OpenFile(today, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"time":`)
+	f.Close()
+	a, err = openArchive(dir, func() time.Time { return day2 })
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.write(privmsg(t, "4", day2))
+	a.write(privmsg(t, "5", day2))
+	a.Close()
+	if got := ids(t, read(t, today)); got != "2 4 5" {
+		t.Errorf("after restart 2022-01-21 has messages %q, want 2 4 5", got)
+	}
+}
+
+func TestArchiveWithoutIDs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmi-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	a, err := openArchive(dir, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer a.Close()
+
+	// A user joins, leaves and joins again, as seen by two connections.
+	var lines []string
+	for _, line := range []string{
+		":a!a@a.tmi.twitch.tv JOIN #nymn",
+		":a!a@a.tmi.twitch.tv PART #nymn",
+		":a!a@a.tmi.twitch.tv JOIN #nymn",
+		"@badges=;color= :tmi.twitch.tv USERSTATE #nymn",
+	} {
+		lines = append(lines, line, line)
+	}
+	for i, line := range lines {
+		events := make(chan tmi.Event, 1)
+		if err := tmi.Replay(context.Background(), strings.NewReader("2022-01-20T12:00:00Z < "+line+"\n"), 0, events); err != nil {
+			t.Fatal(err)
+		}
+		r, ok := newRecord(<-events, time.Now())
+		if !ok {
+			continue
+		}
+		r.conn = i % 2
+		if err := a.write(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	b := read(t, filepath.Join(dir, "nymn", time.Now().UTC().Format(dayLayout)+".jsonl"))
+	var commands []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		var r record
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatal(err)
+		}
+		commands = append(commands, r.Command)
+	}
+	if got := strings.Join(commands, " "); got != "JOIN PART JOIN" {
+		t.Errorf("archived %s, want JOIN PART JOIN", got)
+	}
+}
+
+func TestOpenArchiveCompresses(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tmi-archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "nymn"), 0755)
+	old := filepath.Join(dir, "nymn", "2000-01-01.jsonl")
+	ioutil.WriteFile(old, []byte(`{"channel":"nymn","command":"PRIVMSG","tags":{"id":"1"}}`+"\n"), 0644)
+
+	a, err := openArchive(dir, time.Now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.Close()
+	if _, err := os.Stat(old); !os.IsNotExist(err) {
+		t.Errorf("left over day not removed: %v", err)
+	}
+	if got := ids(t, gunzip(t, old+".gz")); got != "1" {
+		t.Errorf("compressed day has messages %q", got)
+	}
+}
+
+func read(t *testing.T, path string) []byte {
+	t.Helper()
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func gunzip(t *testing.T, path string) []byte {
+	t.Helper()
+	zr, err := gzip.NewReader(bytes.NewReader(read(t, path)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := ioutil.ReadAll(zr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// ids returns the message IDs of the records in b.
+func ids(t *testing.T, b []byte) string {
+	t.Helper()
+	var ids []string
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		i := strings.Index(line, `"id":"`)
+		if i < 0 {
+			t.Fatalf("record without id: %s", line)
+		}
+		ids = append(ids, strings.SplitN(line[i+6:], `"`, 2)[0])
+	}
+	return strings.Join(ids, " ")
+}
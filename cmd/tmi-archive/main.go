@@ -0,0 +1,156 @@
+// Command tmi-archive joins channels anonymously and archives their chat.
+//
+// Every event of a channel is written as a JSON object with decoded tags to
+// DIR/<channel>/<day>.jsonl, days in UTC. A day's file is gzipped once the
+// next day starts, or by the next run. Several redundant connections are
+// kept so that a dropped one leaves no gap; events received on more than
+// one are written once.
+//
+// Usage:
+//
+//	tmi-archive [-dir DIR] [-conns N] [-v] channel...
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+// received is an event, the time it arrived and the connection it arrived on.
+type received struct {
+	event tmi.Event
+	time  time.Time
+	conn  int
+}
+
+func main() {
+	dir := flag.String("dir", "archive", "directory to write the archive to")
+	conns := flag.Int("conns", 2, "number of redundant connections")
+	verbose := flag.Bool("v", false, "log the connections' traffic to stderr")
+	flag.Parse()
+	channels := flag.Args()
+	if len(channels) == 0 || *conns < 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	a, err := openArchive(*dir, time.Now)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "tmi-archive:", err)
+		os.Exit(1)
+	}
+
+	var log io.Writer = ioutil.Discard
+	if *verbose {
+		log = os.Stderr
+	}
+	events := make(chan received)
+	for i := 0; i < *conns; i++ {
+		go connect(i, channels, log, events)
+	}
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+
+	for {
+		select {
+		case ev := <-events:
+			r, ok := newRecord(ev.event, ev.time)
+			if !ok {
+				continue
+			}
+			r.conn = ev.conn
+			if err := a.write(r); err != nil {
+				fmt.Fprintln(os.Stderr, "tmi-archive:", err)
+				a.Close()
+				os.Exit(1)
+			}
+		case <-signals:
+			if err := a.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "tmi-archive:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+}
+
+// connect keeps connection conn joined to channels and sends its events,
+// reconnecting when it drops or the server asks to.
+func connect(conn int, channels []string, log io.Writer, events chan<- received) {
+	backoff := time.Second
+	wait := func() {
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > time.Minute {
+			backoff = time.Minute
+		}
+	}
+	for {
+		c, err := tmi.NewClient(tmi.Log(log))
+		if err == nil {
+			err = c.Connect()
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "tmi-archive: connect:", err, "retrying in", backoff)
+			wait()
+			continue
+		}
+		connected := time.Now()
+		go join(c, channels)
+
+		closed := false
+		for ev := range c.Events() {
+			switch ev.(type) {
+			case tmi.PING:
+				if !closed {
+					c.Default(ev)
+				}
+			case tmi.RECONNECT:
+				// The other connections cover for this one while it
+				// reconnects.
+				c.Close()
+				closed = true
+			}
+			events <- received{ev, time.Now(), conn}
+		}
+		// A connection that drops as soon as it is made must not be
+		// redialed in a tight loop.
+		if time.Since(connected) >= time.Minute {
+			backoff = time.Second
+		}
+		if !closed {
+			c.Close()
+			fmt.Fprintln(os.Stderr, "tmi-archive: connection lost, reconnecting in", backoff)
+			wait()
+		}
+	}
+}
+
+// join channels within Twitch's limit of 20 joins per 10 seconds.
+func join(c *tmi.Client, channels []string) {
+	for len(channels) > 0 {
+		n := 20
+		if n > len(channels) {
+			n = len(channels)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		err := c.Send(ctx, tmi.Join(channels[:n]...))
+		cancel()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "tmi-archive: join:", err)
+			return
+		}
+		channels = channels[n:]
+		if len(channels) > 0 {
+			time.Sleep(10 * time.Second)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+func TestParseInput(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    input
+		wantErr bool
+	}{
+		{line: "hello", want: input{verb: "say", channel: "nymn", text: "hello"}},
+		{line: "/me waves", want: input{verb: "say", channel: "nymn", text: "/me waves"}},
+		{line: "/join #Forsen", want: input{verb: "join", channel: "forsen"}},
+		{line: "/s forsen", want: input{verb: "switch", channel: "forsen"}},
+		{line: "/part", want: input{verb: "part", channel: "nymn"}},
+		{line: "/w someone hi there", want: input{verb: "whisper", user: "someone", text: "hi there"}},
+		{line: "/w someone", wantErr: true},
+		{line: "/nope", wantErr: true},
+		{line: "/ban", wantErr: true},
+		{line: "/slow x", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseInput("nymn", tt.line)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseInput(%q) error = %v", tt.line, err)
+			continue
+		}
+		if !tt.wantErr && (got.verb != tt.want.verb || got.channel != tt.want.channel || got.user != tt.want.user || got.text != tt.want.text) {
+			t.Errorf("parseInput(%q) = %+v, want %+v", tt.line, got, tt.want)
+		}
+	}
+
+	if _, err := parseInput("", "hello"); err != errNoChannel {
+		t.Errorf("saying without a channel = %v, want %v", err, errNoChannel)
+	}
+}
+
+func TestParseModeration(t *testing.T) {
+	tests := []struct {
+		line     string
+		kind     tmi.ActionKind
+		user     string
+		duration time.Duration
+		reason   string
+	}{
+		{"/timeout spammer", tmi.ActionTimeout, "spammer", 10 * time.Minute, ""},
+		{"/timeout spammer 1h links please", tmi.ActionTimeout, "spammer", time.Hour, "links please"},
+		{"/timeout spammer 600 no", tmi.ActionTimeout, "spammer", 10 * time.Minute, "no"},
+		{"/timeout spammer calm down", tmi.ActionTimeout, "spammer", 10 * time.Minute, "calm down"},
+		{"/ban spammer bots", tmi.ActionBan, "spammer", 0, "bots"},
+		{"/slow", tmi.ActionSlow, "", 30 * time.Second, ""},
+		{"/emoteonly", tmi.ActionEmoteOnly, "", 0, ""},
+		{"/vip friend", tmi.ActionVIP, "friend", 0, ""},
+	}
+	for _, tt := range tests {
+		in, err := parseInput("nymn", tt.line)
+		if err != nil {
+			t.Errorf("parseInput(%q) error = %v", tt.line, err)
+			continue
+		}
+		a := in.action
+		if in.verb != "moderate" || a.Kind != tt.kind || a.Channel != "nymn" || a.User != tt.user || a.Duration != tt.duration || a.Reason != tt.reason {
+			t.Errorf("parseInput(%q) = %+v", tt.line, a)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	r := renderer{now: func() time.Time { return time.Date(2022, 1, 20, 12, 34, 0, 0, time.Local) }}
+	parse := func(line string) tmi.Event {
+		t.Helper()
+		ev, ok := event(line)
+		if !ok {
+			t.Fatalf("cannot parse %q", line)
+		}
+		return ev
+	}
+	tests := []struct {
+		line, want string
+	}{
+		{
+			"@badges=moderator/1,subscriber/12;color=;display-name=Ronni :ronni!ronni@ronni.tmi.twitch.tv PRIVMSG #nymn :hi",
+			"12:34 [MS] Ronni: hi",
+		},
+		{
+			"@badges=;display-name=中文 :zh!zh@zh.tmi.twitch.tv PRIVMSG #forsen :\x01ACTION waves\x01",
+			"12:34 #forsen * 中文 (zh) waves",
+		},
+		{
+			"@ban-duration=600 :tmi.twitch.tv CLEARCHAT #nymn :ronni",
+			"12:34 -- ronni was timed out for 10m0s",
+		},
+		{
+			"@msg-id=slow_on :tmi.twitch.tv NOTICE #nymn :This room is now in slow mode.",
+			"12:34 -- This room is now in slow mode.",
+		},
+	}
+	for _, tt := range tests {
+		got, ok := r.render(parse(tt.line), "nymn")
+		if !ok || got != tt.want {
+			t.Errorf("render(%q) = %q, %v; want %q", tt.line, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := r.render(parse(":a!a@a.tmi.twitch.tv JOIN #nymn"), "nymn"); ok {
+		t.Error("JOIN rendered")
+	}
+	change := tmi.ChannelChange{
+		Old: tmi.ChannelState{Name: "nymn", FollowersOnly: -1},
+		New: tmi.ChannelState{Name: "nymn", FollowersOnly: -1, Slow: 30 * time.Second, EmoteOnly: true},
+	}
+	if got, _ := r.render(change, "nymn"); got != "12:34 -- emote-only on, slow mode 30s" {
+		t.Errorf("render(ChannelChange) = %q", got)
+	}
+
+	r.color = true
+	if got := r.name(tmi.Packet{Tags: map[string]string{"color": "#FF8000", "display-name": "A"}}); !strings.Contains(got, "\x1b[38;2;255;128;0mA") {
+		t.Errorf("name() = %q, want colored", got)
+	}
+}
+
+// event parses a line from the server.
+func event(line string) (tmi.Event, bool) {
+	events := make(chan tmi.Event, 1)
+	recording := "2022-01-20T12:34:00Z < " + line
+	if err := tmi.Replay(context.Background(), strings.NewReader(recording), 0, events); err != nil {
+		return nil, false
+	}
+	return <-events, true
+}
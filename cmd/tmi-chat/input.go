@@ -0,0 +1,180 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+// input is what a line typed by the user asks for.
+type input struct {
+	verb    string // say, join, part, switch, channels, whisper, moderate, help or quit
+	channel string
+	user    string
+	text    string
+	action  tmi.Action
+}
+
+var errNoChannel = errors.New("no channel, /join one first")
+
+// moderation commands, given the current channel and their arguments.
+var moderation = map[string]func(channel string, args []string) (tmi.Action, error){
+	"timeout": func(channel string, args []string) (tmi.Action, error) {
+		if len(args) == 0 {
+			return tmi.Action{}, errors.New("usage: /timeout user [duration] [reason]")
+		}
+		d, reason := 10*time.Minute, args[1:]
+		if len(args) > 1 {
+			if parsed, err := parseDuration(args[1]); err == nil {
+				d, reason = parsed, args[2:]
+			}
+		}
+		return tmi.Timeout(channel, args[0], d, strings.Join(reason, " ")), nil
+	},
+	"ban": func(channel string, args []string) (tmi.Action, error) {
+		if len(args) == 0 {
+			return tmi.Action{}, errors.New("usage: /ban user [reason]")
+		}
+		return tmi.Ban(channel, args[0], strings.Join(args[1:], " ")), nil
+	},
+	"unban":  argCommand("unban", "user", tmi.Unban),
+	"mod":    argCommand("mod", "user", tmi.Mod),
+	"unmod":  argCommand("unmod", "user", tmi.Unmod),
+	"vip":    argCommand("vip", "user", tmi.VIP),
+	"unvip":  argCommand("unvip", "user", tmi.Unvip),
+	"delete": argCommand("delete", "message-id", tmi.Delete),
+	"clear":  channelCommand(tmi.Clear),
+	"slow": func(channel string, args []string) (tmi.Action, error) {
+		d := 30 * time.Second
+		if len(args) > 0 {
+			var err error
+			if d, err = parseDuration(args[0]); err != nil {
+				return tmi.Action{}, err
+			}
+		}
+		return tmi.Slow(channel, d), nil
+	},
+	"slowoff": channelCommand(tmi.SlowOff),
+	"followers": func(channel string, args []string) (tmi.Action, error) {
+		var d time.Duration
+		if len(args) > 0 {
+			var err error
+			if d, err = parseDuration(args[0]); err != nil {
+				return tmi.Action{}, err
+			}
+		}
+		return tmi.Followers(channel, d), nil
+	},
+	"followersoff":  channelCommand(tmi.FollowersOff),
+	"subs":          channelCommand(tmi.Subscribers),
+	"subsoff":       channelCommand(tmi.SubscribersOff),
+	"emoteonly":     channelCommand(tmi.EmoteOnly),
+	"emoteonlyoff":  channelCommand(tmi.EmoteOnlyOff),
+	"uniquechat":    channelCommand(tmi.UniqueChat),
+	"uniquechatoff": channelCommand(tmi.UniqueChatOff),
+	"announce": func(channel string, args []string) (tmi.Action, error) {
+		if len(args) == 0 {
+			return tmi.Action{}, errors.New("usage: /announce message")
+		}
+		return tmi.Announce(channel, strings.Join(args, " "), ""), nil
+	},
+	"marker": func(channel string, args []string) (tmi.Action, error) {
+		return tmi.Marker(channel, strings.Join(args, " ")), nil
+	},
+}
+
+// argCommand is a command taking a single argument.
+func argCommand(name, arg string, action func(channel, arg string) tmi.Action) func(string, []string) (tmi.Action, error) {
+	return func(channel string, args []string) (tmi.Action, error) {
+		if len(args) != 1 {
+			return tmi.Action{}, fmt.Errorf("usage: /%s %s", name, arg)
+		}
+		return action(channel, args[0]), nil
+	}
+}
+
+// channelCommand is a command without arguments.
+func channelCommand(action func(channel string) tmi.Action) func(string, []string) (tmi.Action, error) {
+	return func(channel string, args []string) (tmi.Action, error) {
+		return action(channel), nil
+	}
+}
+
+// parseDuration accepts Go durations such as 10m and plain seconds.
+func parseDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// parseInput parses a line typed while chatting in current.
+func parseInput(current, line string) (input, error) {
+	line = strings.TrimSpace(line)
+	if !strings.HasPrefix(line, "/") || strings.HasPrefix(line, "/me ") {
+		if current == "" {
+			return input{}, errNoChannel
+		}
+		return input{verb: "say", channel: current, text: line}, nil
+	}
+	fields := strings.Fields(line[1:])
+	if len(fields) == 0 {
+		return input{}, errors.New("empty command, see /help")
+	}
+	name, args := strings.ToLower(fields[0]), fields[1:]
+	switch name {
+	case "join", "j", "part", "switch", "s":
+		if len(args) != 1 {
+			if name == "part" && len(args) == 0 && current != "" {
+				return input{verb: "part", channel: current}, nil
+			}
+			return input{}, fmt.Errorf("usage: /%s channel", name)
+		}
+		verb := map[string]string{"j": "join", "s": "switch"}[name]
+		if verb == "" {
+			verb = name
+		}
+		return input{verb: verb, channel: strings.ToLower(strings.TrimPrefix(args[0], "#"))}, nil
+	case "w", "whisper":
+		if len(args) < 2 {
+			return input{}, errors.New("usage: /w user message")
+		}
+		return input{verb: "whisper", user: args[0], text: strings.Join(args[1:], " ")}, nil
+	case "channels", "help", "quit":
+		return input{verb: name}, nil
+	}
+	command, ok := moderation[name]
+	if !ok {
+		return input{}, fmt.Errorf("unknown command /%s, see /help", name)
+	}
+	if current == "" {
+		return input{}, errNoChannel
+	}
+	action, err := command(current, args)
+	if err != nil {
+		return input{}, err
+	}
+	return input{verb: "moderate", channel: current, action: action}, nil
+}
+
+// help lists the commands.
+func help() string {
+	var names []string
+	for name := range moderation {
+		names = append(names, "/"+name)
+	}
+	sort.Strings(names)
+	return `Type a message to send it to the current channel.
+  /join channel      join a channel and switch to it
+  /part [channel]    leave a channel
+  /switch channel    send messages to another joined channel
+  /channels          list joined channels
+  /w user message    whisper
+  /quit              exit
+Moderation: ` + strings.Join(names, " ")
+}
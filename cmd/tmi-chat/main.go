@@ -0,0 +1,245 @@
+// Command tmi-chat is a line-oriented Twitch chat client.
+//
+// Messages of the joined channels are shown with their authors' badges and
+// colored display names. Lines typed are sent to the current channel, and
+// lines starting with a slash are commands; type /help for a list. Without
+// -nick and -pass, chat is read anonymously. With -client-id, moderation
+// commands go through the Helix API instead of chat.
+//
+// Usage:
+//
+//	tmi-chat [-nick NICK -pass oauth:TOKEN] [-client-id ID] [-no-color] [channel...]
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/fourst4r/tmi"
+	"github.com/fourst4r/tmi/helix"
+)
+
+// chat is the state of the session, only used from the main loop.
+type chat struct {
+	client   *tmi.Client
+	options  []tmi.Option
+	render   renderer
+	current  string
+	channels []string
+	anon     bool
+	closed   bool        // client was closed after a RECONNECT
+	notes    chan string // results of commands, shown by the main loop
+}
+
+func main() {
+	nick := flag.String("nick", "", "login to chat as")
+	pass := flag.String("pass", os.Getenv("TMI_PASS"), "OAuth token, by default $TMI_PASS")
+	clientID := flag.String("client-id", "", "application client ID for moderation through Helix")
+	noColor := flag.Bool("no-color", false, "do not color names")
+	flag.Parse()
+
+	c := &chat{
+		options: []tmi.Option{tmi.Log(ioutil.Discard)},
+		render:  renderer{color: !*noColor, now: time.Now},
+		anon:    *nick == "",
+		notes:   make(chan string),
+	}
+	if !c.anon {
+		c.options = append(c.options, tmi.Auth(strings.ToLower(*nick), *pass))
+		if *clientID != "" {
+			moderator := helix.NewModerator(helix.New(*clientID, *pass), *nick)
+			c.options = append(c.options, tmi.ModerateWith(moderator))
+		}
+	}
+	if err := c.connect(); err != nil {
+		fmt.Fprintln(os.Stderr, "tmi-chat:", err)
+		os.Exit(1)
+	}
+	for _, channel := range flag.Args() {
+		c.handle(input{verb: "join", channel: strings.ToLower(strings.TrimPrefix(channel, "#"))})
+	}
+
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(os.Stdin)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	events := c.client.Events()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			in, err := parseInput(c.current, line)
+			if err != nil {
+				fmt.Println("!!", err)
+				continue
+			}
+			if in.verb == "quit" {
+				c.client.Close()
+				return
+			}
+			c.handle(in)
+		case note := <-c.notes:
+			fmt.Println(note)
+		case ev, ok := <-events:
+			if !ok {
+				fmt.Println("-- disconnected, reconnecting")
+				if !c.closed {
+					c.client.Close()
+				}
+				if err := c.reconnect(); err != nil {
+					fmt.Fprintln(os.Stderr, "tmi-chat:", err)
+					os.Exit(1)
+				}
+				events = c.client.Events()
+				continue
+			}
+			switch ev.(type) {
+			case tmi.PING:
+				if !c.closed {
+					c.client.Default(ev)
+				}
+			case tmi.RECONNECT:
+				c.client.Close()
+				c.closed = true
+			}
+			if text, ok := c.render.render(ev, c.current); ok {
+				fmt.Println(text)
+			}
+		}
+	}
+}
+
+func (c *chat) connect() error {
+	client, err := tmi.NewClient(c.options...)
+	if err != nil {
+		return err
+	}
+	if err := client.Connect(); err != nil {
+		return err
+	}
+	c.client, c.closed = client, false
+	return nil
+}
+
+// reconnect and join the channels again.
+func (c *chat) reconnect() error {
+	backoff := time.Second
+	for attempt := 0; ; attempt++ {
+		err := c.connect()
+		if err == nil {
+			break
+		}
+		if attempt == 5 {
+			return err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+	if len(c.channels) > 0 {
+		c.send(tmi.Join(c.channels...), "")
+	}
+	return nil
+}
+
+// handle what the user asked for.
+func (c *chat) handle(in input) {
+	switch in.verb {
+	case "say", "whisper":
+		if c.anon {
+			fmt.Println("!! reading anonymously, log in with -nick and -pass to chat")
+			return
+		}
+		if in.verb == "say" {
+			c.send(tmi.Say(in.channel, in.text), "")
+		} else {
+			c.send(tmi.Whisper(in.user, in.text), "")
+		}
+	case "join":
+		if !c.joined(in.channel) {
+			c.channels = append(c.channels, in.channel)
+			c.send(tmi.Join(in.channel), "")
+		}
+		c.current = in.channel
+		fmt.Println("-- chatting in #" + in.channel)
+	case "part":
+		for i, channel := range c.channels {
+			if channel == in.channel {
+				c.channels = append(c.channels[:i], c.channels[i+1:]...)
+				break
+			}
+		}
+		c.send(tmi.Part(in.channel), "-- left #"+in.channel)
+		if c.current == in.channel {
+			c.current = ""
+			if len(c.channels) > 0 {
+				c.current = c.channels[len(c.channels)-1]
+			}
+		}
+	case "switch":
+		if !c.joined(in.channel) {
+			fmt.Println("!! not in #" + in.channel + ", /join it first")
+			return
+		}
+		c.current = in.channel
+		fmt.Println("-- chatting in #" + in.channel)
+	case "channels":
+		for _, channel := range c.channels {
+			mark := " "
+			if channel == c.current {
+				mark = "*"
+			}
+			fmt.Println(mark, "#"+channel)
+		}
+	case "moderate":
+		client := c.client
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			if err := client.Moderate(ctx, in.action); err != nil {
+				c.notes <- "!! " + err.Error()
+			}
+		}()
+	case "help":
+		fmt.Println(help())
+	}
+}
+
+func (c *chat) joined(channel string) bool {
+	for _, joined := range c.channels {
+		if joined == channel {
+			return true
+		}
+	}
+	return false
+}
+
+// send a command and report an error, or done if not empty, once the server
+// has acknowledged it.
+func (c *chat) send(command tmi.Command, done string) {
+	client := c.client
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := client.Deliver(ctx, command); err != nil {
+			c.notes <- "!! " + err.Error()
+		} else if done != "" {
+			c.notes <- done
+		}
+	}()
+}
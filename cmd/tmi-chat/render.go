@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+// renderer formats events for the terminal.
+type renderer struct {
+	color bool
+	now   func() time.Time
+}
+
+// badges are the letters shown for a user's badges, in order.
+var badges = []struct{ name, letter string }{
+	{"broadcaster", "B"},
+	{"staff", "A"},
+	{"admin", "A"},
+	{"moderator", "M"},
+	{"vip", "V"},
+	{"subscriber", "S"},
+}
+
+// palette of name colors for users who did not choose one, as Twitch does.
+var palette = []string{
+	"#FF0000", "#0000FF", "#008000", "#B22222", "#FF7F50",
+	"#9ACD32", "#FF4500", "#2E8B57", "#DAA520", "#D2691E",
+	"#5F9EA0", "#1E90FF", "#FF69B4", "#8A2BE2", "#00FF7F",
+}
+
+// render an event received while chatting in current, false for events
+// that are not shown.
+func (r renderer) render(ev tmi.Event, current string) (string, bool) {
+	switch ev := ev.(type) {
+	case tmi.PRIVMSG:
+		name := r.name(tmi.Packet(ev))
+		text := ev.Message()
+		if strings.HasPrefix(text, "\x01ACTION ") && strings.HasSuffix(text, "\x01") {
+			text = strings.TrimSuffix(strings.TrimPrefix(text, "\x01ACTION "), "\x01")
+			return r.line(tmi.Packet(ev), ev.Channel(), current, "* "+name+" "+text), true
+		}
+		return r.line(tmi.Packet(ev), ev.Channel(), current, name+": "+text), true
+	case tmi.WHISPER:
+		return r.line(tmi.Packet(ev), "", current, "[whisper] "+r.name(tmi.Packet(ev))+": "+ev.Message()), true
+	case tmi.NOTICE:
+		return r.line(tmi.Packet(ev), ev.Channel(), current, "-- "+ev.Message()), true
+	case tmi.USERNOTICE:
+		text := "-- " + tmi.UnescapeTag(ev.Tags["system-msg"])
+		if len(ev.Params) > 1 {
+			text += " " + r.name(tmi.Packet(ev)) + ": " + ev.Message()
+		}
+		return r.line(tmi.Packet(ev), ev.Channel(), current, text), true
+	case tmi.CLEARCHAT:
+		var text string
+		switch ev.Kind() {
+		case tmi.ClearAll:
+			text = "-- chat was cleared"
+		case tmi.ClearBan:
+			text = "-- " + ev.Nick() + " was banned"
+		case tmi.ClearTimeout:
+			text = "-- " + ev.Nick() + " was timed out for " + ev.Duration().String()
+		}
+		return r.line(tmi.Packet(ev), ev.Channel(), current, text), true
+	case tmi.CLEARMSG:
+		login, _ := ev.Login()
+		return r.line(tmi.Packet(ev), ev.Channel(), current, "-- a message from "+login+" was deleted: "+ev.Message()), true
+	case tmi.ChannelChange:
+		changes := roomChanges(ev.Old, ev.New)
+		if len(changes) == 0 {
+			return "", false
+		}
+		return r.line(tmi.Packet{}, ev.New.Name, current, "-- "+strings.Join(changes, ", ")), true
+	}
+	return "", false
+}
+
+// line prefixes text with the time of p and its channel, unless current.
+func (r renderer) line(p tmi.Packet, channel, current, text string) string {
+	t := r.now()
+	if ms, err := strconv.ParseInt(p.Tags["tmi-sent-ts"], 10, 64); err == nil {
+		t = time.Unix(0, ms*int64(time.Millisecond))
+	}
+	prefix := t.Format("15:04") + " "
+	if channel != "" && channel != current {
+		prefix += "#" + channel + " "
+	}
+	return prefix + text
+}
+
+// name renders the badges and display name of the author of p.
+func (r renderer) name(p tmi.Packet) string {
+	login := p.Prefix.Nick
+	name := tmi.UnescapeTag(p.Tags["display-name"])
+	switch {
+	case name == "":
+		name = login
+	case login != "" && !strings.EqualFold(name, login):
+		// Localized display names are shown with the login.
+		name += " (" + login + ")"
+	}
+	if r.color {
+		color := p.Tags["color"]
+		if color == "" {
+			h := fnv.New32a()
+			h.Write([]byte(login))
+			color = palette[h.Sum32()%uint32(len(palette))]
+		}
+		name = colorize(name, color)
+	}
+
+	var letters string
+	set := make(map[string]bool)
+	for _, badge := range strings.Split(p.Tags["badges"], ",") {
+		set[strings.SplitN(badge, "/", 2)[0]] = true
+	}
+	for _, badge := range badges {
+		if set[badge.name] && !strings.Contains(letters, badge.letter) {
+			letters += badge.letter
+		}
+	}
+	if letters != "" {
+		name = "[" + letters + "] " + name
+	}
+	return name
+}
+
+// colorize text with a #RRGGBB color as a 24-bit terminal color.
+func colorize(text, color string) string {
+	rgb, err := strconv.ParseUint(strings.TrimPrefix(color, "#"), 16, 32)
+	if err != nil || len(color) != 7 {
+		return text
+	}
+	return fmt.Sprintf("\x1b[38;2;%d;%d;%dm%s\x1b[0m", rgb>>16, rgb>>8&0xff, rgb&0xff, text)
+}
+
+// roomChanges describes how the room settings changed.
+func roomChanges(before, after tmi.ChannelState) []string {
+	var changes []string
+	toggle := func(name string, was, is bool) {
+		if was != is {
+			changes = append(changes, name+" "+onOff(is))
+		}
+	}
+	toggle("emote-only", before.EmoteOnly, after.EmoteOnly)
+	toggle("unique chat", before.R9K, after.R9K)
+	toggle("subscribers-only", before.SubsOnly, after.SubsOnly)
+	if before.Slow != after.Slow {
+		if after.Slow > 0 {
+			changes = append(changes, "slow mode "+after.Slow.String())
+		} else {
+			changes = append(changes, "slow mode off")
+		}
+	}
+	if before.FollowersOnly != after.FollowersOnly && (before.Name != "" || after.FollowersOnly >= 0) {
+		if after.FollowersOnly >= 0 {
+			changes = append(changes, "followers-only "+after.FollowersOnly.String())
+		} else {
+			changes = append(changes, "followers-only off")
+		}
+	}
+	return changes
+}
+
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
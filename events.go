@@ -0,0 +1,169 @@
+package tmi
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// EventPolicy decides what happens to an event when the consumer of
+// Events() falls behind and the buffer is full.
+type EventPolicy int
+
+const (
+	// Block the read loop until the consumer catches up. A consumer that
+	// is too slow gets the client disconnected by Twitch.
+	Block EventPolicy = iota
+	// DropOldest discards the oldest buffered event to make room.
+	DropOldest
+	// DropNewest discards the event that does not fit.
+	DropNewest
+	// Spill queues events that do not fit in memory, without bound.
+	Spill
+)
+
+func (p EventPolicy) String() string {
+	switch p {
+	case Block:
+		return "block"
+	case DropOldest:
+		return "drop oldest"
+	case DropNewest:
+		return "drop newest"
+	case Spill:
+		return "spill"
+	default:
+		return fmt.Sprintf("EventPolicy(%d)", int(p))
+	}
+}
+
+// EventBuffer buffers up to size events on Events(), and applies policy
+// once the buffer is full. The default is an unbuffered channel that
+// blocks. With a dropping policy and size <= 0, events are dropped
+// whenever the consumer is not waiting for one.
+func EventBuffer(size int, policy EventPolicy) Option {
+	return func(c *Client) {
+		if size < 0 {
+			size = 0
+		}
+		c.eventBuffer, c.eventPolicy = size, policy
+	}
+}
+
+// Dropped returns the number of events discarded because of the
+// EventBuffer policy.
+func (c *Client) Dropped() int64 {
+	return atomic.LoadInt64(&c.dropped)
+}
+
+// deliver an event on Events() according to the policy, returning false if
+// the client was closed.
+func (c *Client) deliver(ev Event) bool {
+	policy := c.eventPolicy
+	if policy == DropOldest && cap(c.events) == 0 {
+		// There is no buffered event to drop instead.
+		policy = DropNewest
+	}
+	switch policy {
+	case DropNewest:
+		select {
+		case c.events <- ev:
+		default:
+			atomic.AddInt64(&c.dropped, 1)
+		}
+	case DropOldest:
+		for {
+			select {
+			case c.events <- ev:
+				return true
+			default:
+			}
+			select {
+			case <-c.events:
+				atomic.AddInt64(&c.dropped, 1)
+			default:
+			}
+		}
+	case Spill:
+		c.spill.push(ev)
+	default:
+		if cap(c.events) > 0 && len(c.events) == cap(c.events) {
+			c.logln("events channel is full, about to block")
+		}
+		select {
+		case c.events <- ev:
+		case <-c.closed:
+			return false
+		}
+	}
+	return true
+}
+
+// pump moves spilled events to out, and closes out once the queue is
+// closed and drained or the client is closed.
+func (c *Client) pump(q *eventQueue, out chan<- Event) {
+	defer close(out)
+	for {
+		ev, ok := q.pop()
+		if !ok {
+			return
+		}
+		select {
+		case out <- ev:
+		case <-c.closed:
+			return
+		}
+	}
+}
+
+// eventQueue is an unbounded FIFO of events.
+type eventQueue struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	events []Event
+	closed bool
+}
+
+func newEventQueue() *eventQueue {
+	q := &eventQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+func (q *eventQueue) push(ev Event) {
+	q.mu.Lock()
+	q.events = append(q.events, ev)
+	q.mu.Unlock()
+	q.cond.Signal()
+}
+
+// pop blocks until an event is queued, or returns false once the queue is
+// closed and empty.
+func (q *eventQueue) pop() (Event, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.events) == 0 && !q.closed {
+		q.cond.Wait()
+	}
+	if len(q.events) == 0 {
+		return nil, false
+	}
+	ev := q.events[0]
+	q.events[0] = nil
+	q.events = q.events[1:]
+	return ev, true
+}
+
+// len returns the number of queued events.
+func (q *eventQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.events)
+}
+
+func (q *eventQueue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	q.cond.Broadcast()
+}
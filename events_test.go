@@ -0,0 +1,104 @@
+package tmi
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEventBuffer(t *testing.T) {
+	tests := []struct {
+		policy  EventPolicy
+		want    []string
+		dropped int64
+	}{
+		{DropNewest, []string{"1", "2"}, 3},
+		{DropOldest, []string{"4", "5"}, 3},
+		{Spill, []string{"1", "2", "3", "4", "5"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			c, _ := NewClient(EventBuffer(2, tt.policy))
+			server, _ := pipeClient(t, c)
+			defer server.Close()
+
+			// None of the policies may block the read loop.
+			written := make(chan struct{})
+			go func() {
+				for i := 1; i <= 5; i++ {
+					fmt.Fprintf(server, ":a!a@a.tmi.twitch.tv PRIVMSG #nymn :%d\r\n", i)
+				}
+				close(written)
+			}()
+			select {
+			case <-written:
+			case <-time.After(time.Second):
+				t.Fatal("read loop blocked")
+			}
+			deadline := time.Now().Add(time.Second)
+			for c.Dropped() != tt.dropped || len(c.events) != 2 {
+				if time.Now().After(deadline) {
+					t.Fatalf("Dropped() = %d with %d buffered, want %d", c.Dropped(), len(c.events), tt.dropped)
+				}
+				time.Sleep(time.Millisecond)
+			}
+
+			for _, want := range tt.want {
+				ev := (<-c.Events()).(PRIVMSG)
+				if got := ev.Message(); got != want {
+					t.Errorf("got message %q, want %q", got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEventBufferUnbuffered(t *testing.T) {
+	for _, policy := range []EventPolicy{DropNewest, DropOldest} {
+		t.Run(policy.String(), func(t *testing.T) {
+			c, _ := NewClient(EventBuffer(0, policy))
+			server, _ := pipeClient(t, c)
+			defer server.Close()
+
+			written := make(chan struct{})
+			go func() {
+				for i := 1; i <= 5; i++ {
+					fmt.Fprintf(server, ":a!a@a.tmi.twitch.tv PRIVMSG #nymn :%d\r\n", i)
+				}
+				close(written)
+			}()
+			select {
+			case <-written:
+			case <-time.After(time.Second):
+				t.Fatal("read loop blocked")
+			}
+			deadline := time.Now().Add(time.Second)
+			for c.Dropped() != 5 {
+				if time.Now().After(deadline) {
+					t.Fatalf("Dropped() = %d, want 5", c.Dropped())
+				}
+				time.Sleep(time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestEventQueue(t *testing.T) {
+	q := newEventQueue()
+	q.push(PING(Packet{Command: "PING"}))
+	q.push(RECONNECT(Packet{Command: "RECONNECT"}))
+	q.close()
+	if ev, ok := q.pop(); !ok {
+		t.Errorf("pop() = %v, %v", ev, ok)
+	} else if _, isPing := ev.(PING); !isPing {
+		t.Errorf("pop() = %T, want PING", ev)
+	}
+	if ev, ok := q.pop(); !ok {
+		t.Errorf("pop() = %v, %v", ev, ok)
+	} else if _, isReconnect := ev.(RECONNECT); !isReconnect {
+		t.Errorf("pop() = %T, want RECONNECT", ev)
+	}
+	if _, ok := q.pop(); ok {
+		t.Error("pop() on closed empty queue = true")
+	}
+}
@@ -0,0 +1,213 @@
+// Package helix is a client for the Twitch Helix API endpoints that replaced
+// the moderation chat commands, and a tmi.Moderator performing moderation
+// actions through them.
+package helix
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultBaseURL is the base URL of the Helix API.
+const DefaultBaseURL = "https://api.twitch.tv/helix"
+
+// Client calls the Helix API on behalf of a user.
+type Client struct {
+	BaseURL    string
+	ClientID   string
+	Token      string // User access token, without the "oauth:" prefix.
+	HTTPClient *http.Client
+}
+
+// New creates a client for the application clientID. The token may be given
+// in the "oauth:" form used with tmi.Auth; it needs the moderator scopes of
+// the endpoints used.
+func New(clientID, token string) *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		ClientID:   clientID,
+		Token:      strings.TrimPrefix(token, "oauth:"),
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// Error is an error response from the API.
+type Error struct {
+	Status  int    `json:"status"`
+	Kind    string `json:"error"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("helix: %d %s: %s", e.Status, e.Kind, e.Message)
+}
+
+// User is a Twitch user.
+type User struct {
+	ID          string `json:"id"`
+	Login       string `json:"login"`
+	DisplayName string `json:"display_name"`
+}
+
+// Users looks up users by login.
+func (c *Client) Users(ctx context.Context, logins ...string) ([]User, error) {
+	query := url.Values{"login": logins}
+	var resp struct {
+		Data []User `json:"data"`
+	}
+	err := c.do(ctx, http.MethodGet, "/users", query, nil, &resp)
+	return resp.Data, err
+}
+
+// Ban bans a user from a channel, or times them out if duration is not 0.
+func (c *Client) Ban(ctx context.Context, broadcasterID, moderatorID, userID string, duration time.Duration, reason string) error {
+	type data struct {
+		UserID   string `json:"user_id"`
+		Duration int    `json:"duration,omitempty"`
+		Reason   string `json:"reason,omitempty"`
+	}
+	body := struct {
+		Data data `json:"data"`
+	}{data{userID, int(duration / time.Second), reason}}
+	return c.do(ctx, http.MethodPost, "/moderation/bans", moderation(broadcasterID, moderatorID), body, nil)
+}
+
+// Unban removes a ban or timeout.
+func (c *Client) Unban(ctx context.Context, broadcasterID, moderatorID, userID string) error {
+	query := moderation(broadcasterID, moderatorID)
+	query.Set("user_id", userID)
+	return c.do(ctx, http.MethodDelete, "/moderation/bans", query, nil, nil)
+}
+
+// DeleteMessages deletes a message from a channel's chat, or every message if
+// messageID is empty.
+func (c *Client) DeleteMessages(ctx context.Context, broadcasterID, moderatorID, messageID string) error {
+	query := moderation(broadcasterID, moderatorID)
+	if messageID != "" {
+		query.Set("message_id", messageID)
+	}
+	return c.do(ctx, http.MethodDelete, "/moderation/chat", query, nil, nil)
+}
+
+// ChatSettings is a partial update of a channel's chat settings. Nil fields
+// are left unchanged.
+type ChatSettings struct {
+	EmoteMode                     *bool `json:"emote_mode,omitempty"`
+	FollowerMode                  *bool `json:"follower_mode,omitempty"`
+	FollowerModeDuration          *int  `json:"follower_mode_duration,omitempty"` // Minutes.
+	SlowMode                      *bool `json:"slow_mode,omitempty"`
+	SlowModeWaitTime              *int  `json:"slow_mode_wait_time,omitempty"` // Seconds.
+	SubscriberMode                *bool `json:"subscriber_mode,omitempty"`
+	UniqueChatMode                *bool `json:"unique_chat_mode,omitempty"`
+	NonModeratorChatDelay         *bool `json:"non_moderator_chat_delay,omitempty"`
+	NonModeratorChatDelayDuration *int  `json:"non_moderator_chat_delay_duration,omitempty"`
+}
+
+// UpdateChatSettings changes a channel's chat settings.
+func (c *Client) UpdateChatSettings(ctx context.Context, broadcasterID, moderatorID string, settings ChatSettings) error {
+	return c.do(ctx, http.MethodPatch, "/chat/settings", moderation(broadcasterID, moderatorID), settings, nil)
+}
+
+// Announce highlights a message in a channel's chat. Color is blue, green,
+// orange, purple or empty for the channel's accent color.
+func (c *Client) Announce(ctx context.Context, broadcasterID, moderatorID, message, color string) error {
+	body := struct {
+		Message string `json:"message"`
+		Color   string `json:"color,omitempty"`
+	}{message, color}
+	return c.do(ctx, http.MethodPost, "/chat/announcements", moderation(broadcasterID, moderatorID), body, nil)
+}
+
+// AddModerator makes a user a moderator. Only the broadcaster can do this.
+func (c *Client) AddModerator(ctx context.Context, broadcasterID, userID string) error {
+	return c.do(ctx, http.MethodPost, "/moderation/moderators", role(broadcasterID, userID), nil, nil)
+}
+
+// RemoveModerator removes a moderator. Only the broadcaster can do this.
+func (c *Client) RemoveModerator(ctx context.Context, broadcasterID, userID string) error {
+	return c.do(ctx, http.MethodDelete, "/moderation/moderators", role(broadcasterID, userID), nil, nil)
+}
+
+// AddVIP makes a user a VIP.
+func (c *Client) AddVIP(ctx context.Context, broadcasterID, userID string) error {
+	return c.do(ctx, http.MethodPost, "/channels/vips", role(broadcasterID, userID), nil, nil)
+}
+
+// RemoveVIP removes a VIP.
+func (c *Client) RemoveVIP(ctx context.Context, broadcasterID, userID string) error {
+	return c.do(ctx, http.MethodDelete, "/channels/vips", role(broadcasterID, userID), nil, nil)
+}
+
+// CreateMarker adds a marker to the live stream of a channel.
+func (c *Client) CreateMarker(ctx context.Context, broadcasterID, description string) error {
+	body := struct {
+		UserID      string `json:"user_id"`
+		Description string `json:"description,omitempty"`
+	}{broadcasterID, description}
+	return c.do(ctx, http.MethodPost, "/streams/markers", nil, body, nil)
+}
+
+func moderation(broadcasterID, moderatorID string) url.Values {
+	return url.Values{"broadcaster_id": {broadcasterID}, "moderator_id": {moderatorID}}
+}
+
+func role(broadcasterID, userID string) url.Values {
+	return url.Values{"broadcaster_id": {broadcasterID}, "user_id": {userID}}
+}
+
+// do sends a request with a JSON body, if not nil, and decodes the JSON
+// response into out, if not nil.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	u := strings.TrimSuffix(c.BaseURL, "/") + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	var r io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, r)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.Token)
+	req.Header.Set("Client-Id", c.ClientID)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		e := &Error{Status: resp.StatusCode, Kind: http.StatusText(resp.StatusCode)}
+		json.Unmarshal(b, e)
+		return e
+	}
+	if out == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, out)
+}
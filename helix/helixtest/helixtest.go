@@ -0,0 +1,326 @@
+// Package helixtest provides an in-memory fake of the Helix API endpoints
+// used by package helix, for testing offline.
+package helixtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Ban is a ban or timeout recorded by the server.
+type Ban struct {
+	ModeratorID string
+	Duration    int // Seconds, 0 for a permanent ban.
+	Reason      string
+}
+
+// Announcement is an announcement recorded by the server.
+type Announcement struct {
+	ModeratorID, Message, Color string
+}
+
+// Settings are a channel's chat settings.
+type Settings struct {
+	EmoteMode            bool `json:"emote_mode"`
+	FollowerMode         bool `json:"follower_mode"`
+	FollowerModeDuration int  `json:"follower_mode_duration"`
+	SlowMode             bool `json:"slow_mode"`
+	SlowModeWaitTime     int  `json:"slow_mode_wait_time"`
+	SubscriberMode       bool `json:"subscriber_mode"`
+	UniqueChatMode       bool `json:"unique_chat_mode"`
+}
+
+type channel struct {
+	bans          map[string]Ban // user ID to ban
+	deleted       []string       // message IDs, empty for clears
+	settings      Settings
+	moderators    map[string]bool
+	vips          map[string]bool
+	announcements []Announcement
+	markers       []string
+}
+
+// Server is a fake Helix API. Its URL is the base URL for a helix.Client.
+// Requests must carry the client ID and token it was created with, and
+// moderation endpoints require the moderator to be the broadcaster or one
+// of their moderators.
+type Server struct {
+	*httptest.Server
+	clientID, token string
+
+	mu       sync.Mutex
+	users    map[string]string // login to ID
+	channels map[string]*channel
+}
+
+// NewServer starts a fake server accepting clientID and token.
+func NewServer(clientID, token string) *Server {
+	s := &Server{
+		clientID: clientID,
+		token:    token,
+		users:    make(map[string]string),
+		channels: make(map[string]*channel),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddUser registers a user.
+func (s *Server) AddUser(login, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[strings.ToLower(login)] = id
+}
+
+// Bans returns the bans and timeouts in a channel by user ID.
+func (s *Server) Bans(broadcasterID string) map[string]Ban {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	bans := make(map[string]Ban)
+	for id, ban := range s.channel(broadcasterID).bans {
+		bans[id] = ban
+	}
+	return bans
+}
+
+// Deleted returns the IDs of the messages deleted in a channel, with an
+// empty ID for every time chat was cleared.
+func (s *Server) Deleted(broadcasterID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.channel(broadcasterID).deleted...)
+}
+
+// Settings returns a channel's chat settings.
+func (s *Server) Settings(broadcasterID string) Settings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.channel(broadcasterID).settings
+}
+
+// Moderators returns the user IDs of a channel's moderators.
+func (s *Server) Moderators(broadcasterID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return keys(s.channel(broadcasterID).moderators)
+}
+
+// VIPs returns the user IDs of a channel's VIPs.
+func (s *Server) VIPs(broadcasterID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return keys(s.channel(broadcasterID).vips)
+}
+
+// Announcements returns the announcements made in a channel.
+func (s *Server) Announcements(broadcasterID string) []Announcement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Announcement(nil), s.channel(broadcasterID).announcements...)
+}
+
+// Markers returns the descriptions of the stream markers of a channel.
+func (s *Server) Markers(broadcasterID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.channel(broadcasterID).markers...)
+}
+
+// channel returns the state of a channel, creating it if necessary.
+// s.mu must be held.
+func (s *Server) channel(broadcasterID string) *channel {
+	ch, ok := s.channels[broadcasterID]
+	if !ok {
+		ch = &channel{
+			bans:       make(map[string]Ban),
+			moderators: make(map[string]bool),
+			vips:       make(map[string]bool),
+		}
+		s.channels[broadcasterID] = ch
+	}
+	return ch
+}
+
+func keys(m map[string]bool) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Authorization") != "Bearer "+s.token || r.Header.Get("Client-Id") != s.clientID {
+		writeError(w, http.StatusUnauthorized, "invalid token or client id")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	q := r.URL.Query()
+	route := r.Method + " " + r.URL.Path
+	if route == "GET /users" {
+		type user struct {
+			ID          string `json:"id"`
+			Login       string `json:"login"`
+			DisplayName string `json:"display_name"`
+		}
+		var users []user
+		for _, login := range q["login"] {
+			if id, ok := s.users[strings.ToLower(login)]; ok {
+				users = append(users, user{id, strings.ToLower(login), login})
+			}
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": users})
+		return
+	}
+	if route == "POST /streams/markers" {
+		var body struct {
+			UserID      string `json:"user_id"`
+			Description string `json:"description"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		ch := s.channel(body.UserID)
+		ch.markers = append(ch.markers, body.Description)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": []interface{}{map[string]string{"description": body.Description}}})
+		return
+	}
+
+	broadcaster := q.Get("broadcaster_id")
+	if broadcaster == "" {
+		writeError(w, http.StatusBadRequest, "missing broadcaster_id")
+		return
+	}
+	ch := s.channel(broadcaster)
+
+	switch route {
+	case "POST /moderation/moderators":
+		ch.moderators[q.Get("user_id")] = true
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case "DELETE /moderation/moderators":
+		delete(ch.moderators, q.Get("user_id"))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case "POST /channels/vips":
+		ch.vips[q.Get("user_id")] = true
+		w.WriteHeader(http.StatusNoContent)
+		return
+	case "DELETE /channels/vips":
+		delete(ch.vips, q.Get("user_id"))
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	moderator := q.Get("moderator_id")
+	if moderator != broadcaster && !ch.moderators[moderator] {
+		writeError(w, http.StatusForbidden, "the user in moderator_id is not one of the broadcaster's moderators")
+		return
+	}
+
+	switch route {
+	case "POST /moderation/bans":
+		var body struct {
+			Data struct {
+				UserID   string `json:"user_id"`
+				Duration int    `json:"duration"`
+				Reason   string `json:"reason"`
+			} `json:"data"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		if body.Data.UserID == broadcaster {
+			writeError(w, http.StatusBadRequest, "the user may not be banned")
+			return
+		}
+		ch.bans[body.Data.UserID] = Ban{moderator, body.Data.Duration, body.Data.Reason}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": []interface{}{body.Data}})
+	case "DELETE /moderation/bans":
+		if _, ok := ch.bans[q.Get("user_id")]; !ok {
+			writeError(w, http.StatusBadRequest, "the user is not banned")
+			return
+		}
+		delete(ch.bans, q.Get("user_id"))
+		w.WriteHeader(http.StatusNoContent)
+	case "DELETE /moderation/chat":
+		ch.deleted = append(ch.deleted, q.Get("message_id"))
+		w.WriteHeader(http.StatusNoContent)
+	case "PATCH /chat/settings":
+		var body struct {
+			EmoteMode            *bool `json:"emote_mode"`
+			FollowerMode         *bool `json:"follower_mode"`
+			FollowerModeDuration *int  `json:"follower_mode_duration"`
+			SlowMode             *bool `json:"slow_mode"`
+			SlowModeWaitTime     *int  `json:"slow_mode_wait_time"`
+			SubscriberMode       *bool `json:"subscriber_mode"`
+			UniqueChatMode       *bool `json:"unique_chat_mode"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		set := &ch.settings
+		setBool(&set.EmoteMode, body.EmoteMode)
+		setBool(&set.FollowerMode, body.FollowerMode)
+		setInt(&set.FollowerModeDuration, body.FollowerModeDuration)
+		setBool(&set.SlowMode, body.SlowMode)
+		setInt(&set.SlowModeWaitTime, body.SlowModeWaitTime)
+		setBool(&set.SubscriberMode, body.SubscriberMode)
+		setBool(&set.UniqueChatMode, body.UniqueChatMode)
+		writeJSON(w, http.StatusOK, map[string]interface{}{"data": []Settings{*set}})
+	case "POST /chat/announcements":
+		var body struct {
+			Message string `json:"message"`
+			Color   string `json:"color"`
+		}
+		if !readJSON(w, r, &body) {
+			return
+		}
+		ch.announcements = append(ch.announcements, Announcement{moderator, body.Message, body.Color})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, "no such endpoint: "+route)
+	}
+}
+
+func setBool(dst *bool, src *bool) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func setInt(dst *int, src *int) {
+	if src != nil {
+		*dst = *src
+	}
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{
+		"error":   http.StatusText(status),
+		"status":  status,
+		"message": message,
+	})
+}
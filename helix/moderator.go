@@ -0,0 +1,151 @@
+package helix
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fourst4r/tmi"
+)
+
+// Moderator performs tmi moderation actions through the API, resolving
+// logins to user IDs as needed. Use it with tmi.ModerateWith:
+//
+//	h := helix.New(clientID, pass)
+//	c, err := tmi.NewClient(tmi.Auth(nick, pass), tmi.ModerateWith(helix.NewModerator(h, nick)))
+type Moderator struct {
+	client *Client
+	login  string // of the moderator
+
+	mu  sync.Mutex
+	ids map[string]string // login to user ID
+}
+
+// NewModerator creates a Moderator acting as the user login, which must be
+// the owner of the client's token.
+func NewModerator(client *Client, login string) *Moderator {
+	return &Moderator{client: client, login: strings.ToLower(login), ids: make(map[string]string)}
+}
+
+// Moderate performs an action.
+func (m *Moderator) Moderate(ctx context.Context, a tmi.Action) error {
+	if err := a.Err(); err != nil {
+		return err
+	}
+	logins := []string{a.Channel, m.login}
+	if a.User != "" {
+		logins = append(logins, a.User)
+	}
+	ids, err := m.resolve(ctx, logins...)
+	if err != nil {
+		return err
+	}
+	broadcaster, moderator := ids[0], ids[1]
+	var user string
+	if a.User != "" {
+		user = ids[2]
+	}
+
+	c := m.client
+	switch a.Kind {
+	case tmi.ActionTimeout:
+		return c.Ban(ctx, broadcaster, moderator, user, a.Duration, a.Reason)
+	case tmi.ActionBan:
+		return c.Ban(ctx, broadcaster, moderator, user, 0, a.Reason)
+	case tmi.ActionUnban:
+		return c.Unban(ctx, broadcaster, moderator, user)
+	case tmi.ActionDelete:
+		return c.DeleteMessages(ctx, broadcaster, moderator, a.MessageID)
+	case tmi.ActionClear:
+		return c.DeleteMessages(ctx, broadcaster, moderator, "")
+	case tmi.ActionMod:
+		return c.AddModerator(ctx, broadcaster, user)
+	case tmi.ActionUnmod:
+		return c.RemoveModerator(ctx, broadcaster, user)
+	case tmi.ActionVIP:
+		return c.AddVIP(ctx, broadcaster, user)
+	case tmi.ActionUnvip:
+		return c.RemoveVIP(ctx, broadcaster, user)
+	case tmi.ActionAnnounce:
+		return c.Announce(ctx, broadcaster, moderator, a.Message, a.Color)
+	case tmi.ActionMarker:
+		return c.CreateMarker(ctx, broadcaster, a.Message)
+	}
+
+	settings, ok := chatSettings(a)
+	if !ok {
+		return fmt.Errorf("helix: unsupported action %v", a.Kind)
+	}
+	return c.UpdateChatSettings(ctx, broadcaster, moderator, settings)
+}
+
+// chatSettings returns the settings update performing a chat mode action.
+func chatSettings(a tmi.Action) (ChatSettings, bool) {
+	on, off := true, false
+	var s ChatSettings
+	switch a.Kind {
+	case tmi.ActionSlow:
+		seconds := int(a.Duration / time.Second)
+		s.SlowMode, s.SlowModeWaitTime = &on, &seconds
+	case tmi.ActionSlowOff:
+		s.SlowMode = &off
+	case tmi.ActionFollowers:
+		minutes := int(a.Duration / time.Minute)
+		s.FollowerMode, s.FollowerModeDuration = &on, &minutes
+	case tmi.ActionFollowersOff:
+		s.FollowerMode = &off
+	case tmi.ActionSubscribers:
+		s.SubscriberMode = &on
+	case tmi.ActionSubscribersOff:
+		s.SubscriberMode = &off
+	case tmi.ActionEmoteOnly:
+		s.EmoteMode = &on
+	case tmi.ActionEmoteOnlyOff:
+		s.EmoteMode = &off
+	case tmi.ActionUniqueChat:
+		s.UniqueChatMode = &on
+	case tmi.ActionUniqueChatOff:
+		s.UniqueChatMode = &off
+	default:
+		return s, false
+	}
+	return s, true
+}
+
+// resolve returns the user IDs of logins, in order.
+func (m *Moderator) resolve(ctx context.Context, logins ...string) ([]string, error) {
+	m.mu.Lock()
+	var missing []string
+	for _, login := range logins {
+		if _, ok := m.ids[login]; !ok {
+			missing = append(missing, login)
+		}
+	}
+	m.mu.Unlock()
+
+	if len(missing) > 0 {
+		users, err := m.client.Users(ctx, missing...)
+		if err != nil {
+			return nil, err
+		}
+		m.mu.Lock()
+		for _, u := range users {
+			m.ids[strings.ToLower(u.Login)] = u.ID
+		}
+		m.mu.Unlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ids := make([]string, len(logins))
+	for i, login := range logins {
+		id, ok := m.ids[login]
+		if !ok {
+			return nil, fmt.Errorf("helix: unknown user %q", login)
+		}
+		ids[i] = id
+	}
+	return ids, nil
+}
@@ -0,0 +1,105 @@
+package helix_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi"
+	"github.com/fourst4r/tmi/helix"
+	"github.com/fourst4r/tmi/helix/helixtest"
+)
+
+func TestModerator(t *testing.T) {
+	s := helixtest.NewServer("clientid", "token")
+	defer s.Close()
+	s.AddUser("bar", "1")
+	s.AddUser("bot", "2")
+	s.AddUser("foo", "3")
+
+	h := helix.New("clientid", "oauth:token")
+	h.BaseURL = s.URL
+	c, err := tmi.NewClient(tmi.Auth("bot", "oauth:token"), tmi.ModerateWith(helix.NewModerator(h, "bot")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	var herr *helix.Error
+	if err := c.Moderate(ctx, tmi.Ban("bar", "foo", "")); !errors.As(err, &herr) || herr.Status != 403 {
+		t.Fatalf("Moderate() as non-moderator = %v, want 403", err)
+	}
+	if err := h.AddModerator(ctx, "1", "2"); err != nil {
+		t.Fatal(err)
+	}
+
+	actions := []tmi.Action{
+		tmi.Timeout("bar", "foo", 10*time.Minute, "spam"),
+		tmi.Delete("bar", "abc"),
+		tmi.Clear("bar"),
+		tmi.Slow("bar", 30*time.Second),
+		tmi.Followers("bar", time.Hour),
+		tmi.Subscribers("bar"),
+		tmi.EmoteOnly("bar"),
+		tmi.EmoteOnlyOff("bar"),
+		tmi.UniqueChat("bar"),
+		tmi.VIP("bar", "foo"),
+		tmi.Announce("bar", "hello", "blue"),
+		tmi.Marker("bar", "good play"),
+	}
+	for _, a := range actions {
+		if err := c.Moderate(ctx, a); err != nil {
+			t.Fatalf("Moderate(%v) = %v", a.Kind, err)
+		}
+	}
+
+	if got, want := s.Bans("1"), map[string]helixtest.Ban{"3": {ModeratorID: "2", Duration: 600, Reason: "spam"}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("bans = %v, want %v", got, want)
+	}
+	if got, want := s.Deleted("1"), []string{"abc", ""}; !reflect.DeepEqual(got, want) {
+		t.Errorf("deleted = %q, want %q", got, want)
+	}
+	want := helixtest.Settings{
+		FollowerMode:         true,
+		FollowerModeDuration: 60,
+		SlowMode:             true,
+		SlowModeWaitTime:     30,
+		SubscriberMode:       true,
+		UniqueChatMode:       true,
+	}
+	if got := s.Settings("1"); got != want {
+		t.Errorf("settings = %+v, want %+v", got, want)
+	}
+	if got := s.VIPs("1"); !reflect.DeepEqual(got, []string{"3"}) {
+		t.Errorf("vips = %v", got)
+	}
+	if got := s.Announcements("1"); !reflect.DeepEqual(got, []helixtest.Announcement{{ModeratorID: "2", Message: "hello", Color: "blue"}}) {
+		t.Errorf("announcements = %v", got)
+	}
+	if got := s.Markers("1"); !reflect.DeepEqual(got, []string{"good play"}) {
+		t.Errorf("markers = %v", got)
+	}
+
+	if err := c.Moderate(ctx, tmi.Unban("bar", "foo")); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Bans("1"); len(got) != 0 {
+		t.Errorf("bans after unban = %v", got)
+	}
+	if err := c.Moderate(ctx, tmi.Ban("bar", "nobody", "")); err == nil {
+		t.Error("Moderate() of an unknown user succeeded")
+	}
+}
+
+func TestClientUnauthorized(t *testing.T) {
+	s := helixtest.NewServer("clientid", "token")
+	defer s.Close()
+	h := helix.New("clientid", "wrong")
+	h.BaseURL = s.URL
+	var herr *helix.Error
+	if _, err := h.Users(context.Background(), "bar"); !errors.As(err, &herr) || herr.Status != 401 {
+		t.Errorf("Users() = %v, want 401", err)
+	}
+}
@@ -0,0 +1,141 @@
+package tmi
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter is a sliding window rate limiter.
+type limiter struct {
+	mu   sync.Mutex
+	n    int
+	per  time.Duration
+	sent []time.Time
+	now  func() time.Time
+}
+
+func newLimiter(n int, per time.Duration) *limiter {
+	return &limiter{n: n, per: per, now: time.Now}
+}
+
+// allow reports whether n more events fit in the window, and records them
+// if they do. A nil limiter allows everything.
+func (l *limiter) allow(n int) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.fits(now, n) {
+		return false
+	}
+	l.record(now, n)
+	return true
+}
+
+// check reports whether n more events fit in the window, without recording
+// them. A nil limiter allows everything.
+func (l *limiter) check(n int) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fits(l.now(), n)
+}
+
+// add records n events. A nil limiter records nothing.
+func (l *limiter) add(n int) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.record(l.now(), n)
+}
+
+// fits reports whether n more events fit in the window. l.mu must be held.
+func (l *limiter) fits(now time.Time, n int) bool {
+	i := 0
+	for i < len(l.sent) && now.Sub(l.sent[i]) >= l.per {
+		i++
+	}
+	l.sent = l.sent[i:]
+	return len(l.sent)+n <= l.n
+}
+
+// record n events. l.mu must be held.
+func (l *limiter) record(now time.Time, n int) {
+	for ; n > 0; n-- {
+		l.sent = append(l.sent, now)
+	}
+}
+
+// whisperLimiter enforces Twitch's whisper limits: a number of whispers per
+// second and per minute, and of distinct recipients per day.
+type whisperLimiter struct {
+	mu            sync.Mutex
+	perSecond     *limiter
+	perMinute     *limiter
+	recipients    map[string]time.Time // recipient to first whisper today
+	maxRecipients int
+	now           func() time.Time
+}
+
+func newWhisperLimiter(perSecond, perMinute, recipients int) *whisperLimiter {
+	return &whisperLimiter{
+		perSecond:     newLimiter(perSecond, time.Second),
+		perMinute:     newLimiter(perMinute, time.Minute),
+		recipients:    make(map[string]time.Time),
+		maxRecipients: recipients,
+		now:           time.Now,
+	}
+}
+
+// check reports whether whispers to recipients are allowed, without
+// recording them. A nil whisperLimiter allows everything.
+func (w *whisperLimiter) check(recipients ...string) bool {
+	if w == nil {
+		return true
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	for r, first := range w.recipients {
+		if now.Sub(first) >= 24*time.Hour {
+			delete(w.recipients, r)
+		}
+	}
+	unknown := make(map[string]bool)
+	for _, recipient := range recipients {
+		if _, known := w.recipients[recipient]; !known {
+			unknown[recipient] = true
+		}
+	}
+	if len(w.recipients)+len(unknown) > w.maxRecipients {
+		return false
+	}
+	n := len(recipients)
+	return w.perSecond.fits(now, n) && w.perMinute.fits(now, n)
+}
+
+// add records whispers to recipients. A nil whisperLimiter records nothing.
+func (w *whisperLimiter) add(recipients ...string) {
+	if w == nil {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := w.now()
+	w.perSecond.record(now, len(recipients))
+	w.perMinute.record(now, len(recipients))
+	for _, recipient := range recipients {
+		if _, known := w.recipients[recipient]; !known {
+			w.recipients[recipient] = now
+		}
+	}
+}
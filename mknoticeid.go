@@ -0,0 +1,96 @@
+//go:build ignore
+// +build ignore
+
+// mknoticeid generates noticeids.go from noticeids.txt.
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+)
+
+var categories = map[string]string{
+	"rejection": "RejectionNotice",
+	"error":     "ErrorNotice",
+	"state":     "StateNotice",
+	"info":      "InfoNotice",
+}
+
+type notice struct {
+	id, name, category, message string
+}
+
+func main() {
+	f, err := os.Open("noticeids.txt")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+
+	var notices []notice
+	s := bufio.NewScanner(f)
+	for line := 1; s.Scan(); line++ {
+		text := s.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.SplitN(text, "\t", 3)
+		if len(fields) != 3 {
+			log.Fatalf("noticeids.txt:%d: want 3 tab separated fields", line)
+		}
+		category, ok := categories[fields[1]]
+		if !ok {
+			log.Fatalf("noticeids.txt:%d: unknown category %q", line, fields[1])
+		}
+		notices = append(notices, notice{fields[0], name(fields[0]), category, fields[2]})
+	}
+	if err := s.Err(); err != nil {
+		log.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintln(&buf, "// Code generated by mknoticeid.go; DO NOT EDIT.")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "package tmi")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "const (")
+	for _, n := range notices {
+		fmt.Fprintf(&buf, "\t// %s\n", n.message)
+		fmt.Fprintf(&buf, "\t%s NoticeID = %q\n", n.name, n.id)
+	}
+	fmt.Fprintln(&buf, ")")
+	fmt.Fprintln(&buf)
+	fmt.Fprintln(&buf, "var noticeCategories = map[NoticeID]NoticeCategory{")
+	for _, n := range notices {
+		fmt.Fprintf(&buf, "\t%s: %s,\n", n.name, n.category)
+	}
+	fmt.Fprintln(&buf, "}")
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := ioutil.WriteFile("noticeids.go", src, 0644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// name turns a msg-id such as msg_banned into NoticeMsgBanned.
+func name(id string) string {
+	var b strings.Builder
+	b.WriteString("Notice")
+	for _, word := range strings.Split(id, "_") {
+		if word == "" {
+			continue
+		}
+		b.WriteString(strings.ToUpper(word[:1]))
+		b.WriteString(word[1:])
+	}
+	return b.String()
+}
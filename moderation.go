@@ -0,0 +1,270 @@
+package tmi
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ActionKind is the kind of a moderation Action.
+type ActionKind int
+
+const (
+	ActionTimeout ActionKind = iota + 1
+	ActionBan
+	ActionUnban
+	ActionDelete
+	ActionClear
+	ActionSlow
+	ActionSlowOff
+	ActionFollowers
+	ActionFollowersOff
+	ActionSubscribers
+	ActionSubscribersOff
+	ActionEmoteOnly
+	ActionEmoteOnlyOff
+	ActionUniqueChat
+	ActionUniqueChatOff
+	ActionMod
+	ActionUnmod
+	ActionVIP
+	ActionUnvip
+	ActionAnnounce
+	ActionMarker
+)
+
+// commands are the chat commands performing each kind of action.
+var commands = map[ActionKind]string{
+	ActionTimeout:        "/timeout",
+	ActionBan:            "/ban",
+	ActionUnban:          "/unban",
+	ActionDelete:         "/delete",
+	ActionClear:          "/clear",
+	ActionSlow:           "/slow",
+	ActionSlowOff:        "/slowoff",
+	ActionFollowers:      "/followers",
+	ActionFollowersOff:   "/followersoff",
+	ActionSubscribers:    "/subscribers",
+	ActionSubscribersOff: "/subscribersoff",
+	ActionEmoteOnly:      "/emoteonly",
+	ActionEmoteOnlyOff:   "/emoteonlyoff",
+	ActionUniqueChat:     "/uniquechat",
+	ActionUniqueChatOff:  "/uniquechatoff",
+	ActionMod:            "/mod",
+	ActionUnmod:          "/unmod",
+	ActionVIP:            "/vip",
+	ActionUnvip:          "/unvip",
+	ActionAnnounce:       "/announce",
+	ActionMarker:         "/marker",
+}
+
+func (k ActionKind) String() string {
+	if command, ok := commands[k]; ok {
+		return command[1:]
+	}
+	return "ActionKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Action is a moderation action in a channel. Create one with the
+// constructors such as Timeout and Ban, and perform it with
+// Client.Moderate, or send its Command.
+type Action struct {
+	Kind    ActionKind
+	Channel string
+	User    string // Login of the target of Timeout, Ban, Unban, Mod, Unmod, VIP and Unvip.
+
+	Duration  time.Duration // Of Timeout, Slow and Followers.
+	Reason    string        // Of Timeout and Ban.
+	MessageID string        // Of Delete.
+	Message   string        // Of Announce, or the description of Marker.
+	Color     string        // Of Announce: blue, green, orange, purple or empty.
+
+	err error // set by constructors given invalid arguments
+}
+
+// MaxTimeout is the longest a user can be timed out for.
+const MaxTimeout = 14 * 24 * time.Hour
+
+// Timeout a user in a channel for d, rounded down to the second.
+func Timeout(channel, user string, d time.Duration, reason string) Action {
+	a := userAction(ActionTimeout, channel, user)
+	a.Duration, a.Reason = d, reason
+	if d < time.Second || d > MaxTimeout {
+		a.invalid("timeout duration %v out of range", d)
+	}
+	return a
+}
+
+// Ban a user from a channel.
+func Ban(channel, user, reason string) Action {
+	a := userAction(ActionBan, channel, user)
+	a.Reason = reason
+	return a
+}
+
+// Unban a user from a channel, also removing a timeout.
+func Unban(channel, user string) Action { return userAction(ActionUnban, channel, user) }
+
+// Delete a message in a channel by its ID.
+func Delete(channel, messageID string) Action {
+	a := action(ActionDelete, channel)
+	a.MessageID = messageID
+	if messageID == "" || strings.ContainsAny(messageID, " \r\n\x00") {
+		a.invalid("invalid message id %q", messageID)
+	}
+	return a
+}
+
+// Clear the chat history of a channel.
+func Clear(channel string) Action { return action(ActionClear, channel) }
+
+// Slow limits users to sending a message every d.
+func Slow(channel string, d time.Duration) Action {
+	a := action(ActionSlow, channel)
+	a.Duration = d
+	if d < time.Second || d > 120*time.Second {
+		a.invalid("slow mode duration %v out of range", d)
+	}
+	return a
+}
+
+// SlowOff disables slow mode.
+func SlowOff(channel string) Action { return action(ActionSlowOff, channel) }
+
+// Followers restricts chat to users who have followed for at least d.
+func Followers(channel string, d time.Duration) Action {
+	a := action(ActionFollowers, channel)
+	a.Duration = d
+	if d < 0 || d > 90*24*time.Hour {
+		a.invalid("followers-only duration %v out of range", d)
+	}
+	return a
+}
+
+// FollowersOff disables followers-only mode.
+func FollowersOff(channel string) Action { return action(ActionFollowersOff, channel) }
+
+// Subscribers restricts chat to subscribers.
+func Subscribers(channel string) Action { return action(ActionSubscribers, channel) }
+
+// SubscribersOff disables subscribers-only mode.
+func SubscribersOff(channel string) Action { return action(ActionSubscribersOff, channel) }
+
+// EmoteOnly restricts chat to emotes.
+func EmoteOnly(channel string) Action { return action(ActionEmoteOnly, channel) }
+
+// EmoteOnlyOff disables emote-only mode.
+func EmoteOnlyOff(channel string) Action { return action(ActionEmoteOnlyOff, channel) }
+
+// UniqueChat rejects messages that aren't unique, also known as r9k.
+func UniqueChat(channel string) Action { return action(ActionUniqueChat, channel) }
+
+// UniqueChatOff disables unique-chat mode.
+func UniqueChatOff(channel string) Action { return action(ActionUniqueChatOff, channel) }
+
+// Mod makes a user a moderator of a channel.
+func Mod(channel, user string) Action { return userAction(ActionMod, channel, user) }
+
+// Unmod removes a moderator.
+func Unmod(channel, user string) Action { return userAction(ActionUnmod, channel, user) }
+
+// VIP makes a user a VIP of a channel.
+func VIP(channel, user string) Action { return userAction(ActionVIP, channel, user) }
+
+// Unvip removes a VIP.
+func Unvip(channel, user string) Action { return userAction(ActionUnvip, channel, user) }
+
+// Announce highlights a message in a channel. Color is blue, green, orange,
+// purple or empty for the channel's accent color.
+func Announce(channel, message, color string) Action {
+	a := action(ActionAnnounce, channel)
+	a.Message, a.Color = message, color
+	switch color {
+	case "", "blue", "green", "orange", "purple":
+	default:
+		a.invalid("invalid announcement color %q", color)
+	}
+	return a
+}
+
+// Marker adds a stream marker with an optional description.
+func Marker(channel, description string) Action {
+	a := action(ActionMarker, channel)
+	a.Message = description
+	return a
+}
+
+func action(kind ActionKind, channel string) Action {
+	name, err := normalizeChannel(channel)
+	return Action{Kind: kind, Channel: name, err: err}
+}
+
+func userAction(kind ActionKind, channel, user string) Action {
+	a := action(kind, channel)
+	login, err := normalizeLogin(user)
+	a.User = login
+	if a.err == nil {
+		a.err = err
+	}
+	return a
+}
+
+func (a *Action) invalid(format string, args ...interface{}) {
+	if a.err == nil {
+		a.err = fmt.Errorf("tmi: %v: "+format, append([]interface{}{a.Kind}, args...)...)
+	}
+}
+
+// Err returns the error the action was constructed with, if any.
+func (a Action) Err() error { return a.err }
+
+// Command performs the action as a chat command.
+func (a Action) Command() Command {
+	if a.err != nil {
+		return fail(a.err)
+	}
+	command, ok := commands[a.Kind]
+	if !ok {
+		return fail(fmt.Errorf("tmi: unknown action %v", a.Kind))
+	}
+	args := []string{command}
+	switch a.Kind {
+	case ActionTimeout:
+		args = append(args, a.User, strconv.Itoa(int(a.Duration/time.Second)), a.Reason)
+	case ActionBan:
+		args = append(args, a.User, a.Reason)
+	case ActionUnban, ActionMod, ActionUnmod, ActionVIP, ActionUnvip:
+		args = append(args, a.User)
+	case ActionDelete:
+		args = append(args, a.MessageID)
+	case ActionSlow:
+		args = append(args, strconv.Itoa(int(a.Duration/time.Second)))
+	case ActionFollowers:
+		args = append(args, strconv.Itoa(int(a.Duration/time.Minute))+"m")
+	case ActionAnnounce:
+		args = []string{command + a.Color, a.Message}
+	case ActionMarker:
+		args = append(args, a.Message)
+	}
+	return Say(a.Channel, strings.TrimSpace(strings.Join(args, " ")))
+}
+
+// Moderator performs moderation actions, e.g. through Twitch's HTTP API
+// instead of chat commands. See ModerateWith.
+type Moderator interface {
+	Moderate(ctx context.Context, action Action) error
+}
+
+// Moderate performs a moderation action through the Moderator set with
+// ModerateWith, by default by sending its chat command.
+func (c *Client) Moderate(ctx context.Context, action Action) error {
+	if err := action.Err(); err != nil {
+		return err
+	}
+	if c.moderator != nil {
+		return c.moderator.Moderate(ctx, action)
+	}
+	return c.Send(ctx, action.Command())
+}
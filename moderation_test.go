@@ -0,0 +1,79 @@
+package tmi
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestActionCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		action  Action
+		want    string
+		wantErr bool
+	}{
+		{"timeout", Timeout("#Bar", "Foo", 10*time.Minute, "spam"), "PRIVMSG #bar :/timeout foo 600 spam", false},
+		{"timeout no reason", Timeout("bar", "foo", time.Second, ""), "PRIVMSG #bar :/timeout foo 1", false},
+		{"timeout too short", Timeout("bar", "foo", 0, ""), "", true},
+		{"timeout too long", Timeout("bar", "foo", MaxTimeout+time.Second, ""), "", true},
+		{"ban", Ban("bar", "foo", "being rude"), "PRIVMSG #bar :/ban foo being rude", false},
+		{"ban bad user", Ban("bar", "foo bar", ""), "", true},
+		{"unban", Unban("bar", "foo"), "PRIVMSG #bar :/unban foo", false},
+		{"delete", Delete("bar", "abc-123"), "PRIVMSG #bar :/delete abc-123", false},
+		{"delete no id", Delete("bar", ""), "", true},
+		{"clear", Clear("bar"), "PRIVMSG #bar :/clear", false},
+		{"slow", Slow("bar", 30*time.Second), "PRIVMSG #bar :/slow 30", false},
+		{"slow too long", Slow("bar", time.Hour), "", true},
+		{"slow off", SlowOff("bar"), "PRIVMSG #bar :/slowoff", false},
+		{"followers", Followers("bar", 10*time.Minute), "PRIVMSG #bar :/followers 10m", false},
+		{"followers off", FollowersOff("bar"), "PRIVMSG #bar :/followersoff", false},
+		{"subscribers", Subscribers("bar"), "PRIVMSG #bar :/subscribers", false},
+		{"emote only off", EmoteOnlyOff("bar"), "PRIVMSG #bar :/emoteonlyoff", false},
+		{"unique chat", UniqueChat("bar"), "PRIVMSG #bar :/uniquechat", false},
+		{"mod", Mod("bar", "foo"), "PRIVMSG #bar :/mod foo", false},
+		{"unvip", Unvip("bar", "foo"), "PRIVMSG #bar :/unvip foo", false},
+		{"announce", Announce("bar", "hello all", ""), "PRIVMSG #bar :/announce hello all", false},
+		{"announce blue", Announce("bar", "hello all", "blue"), "PRIVMSG #bar :/announceblue hello all", false},
+		{"announce bad color", Announce("bar", "hello", "red"), "", true},
+		{"marker", Marker("bar", "good play"), "PRIVMSG #bar :/marker good play", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.action.Command()(&buf)
+			if (err != nil) != tt.wantErr || (tt.action.Err() != nil) != tt.wantErr {
+				t.Fatalf("Command() error = %v, Err() = %v, wantErr %v", err, tt.action.Err(), tt.wantErr)
+			}
+			if tt.want != "" {
+				tt.want += Delim
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("Command() wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type moderatorFunc func(context.Context, Action) error
+
+func (f moderatorFunc) Moderate(ctx context.Context, a Action) error { return f(ctx, a) }
+
+func TestModerateWith(t *testing.T) {
+	var got []Action
+	c, _ := NewClient(ModerateWith(moderatorFunc(func(_ context.Context, a Action) error {
+		got = append(got, a)
+		return nil
+	})))
+	ctx := context.Background()
+	if err := c.Moderate(ctx, Ban("bar", "Foo", "")); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Moderate(ctx, Slow("bar", time.Hour)); err == nil {
+		t.Error("Moderate() with an invalid action succeeded")
+	}
+	if len(got) != 1 || got[0].Kind != ActionBan || got[0].User != "foo" {
+		t.Errorf("moderator got %+v", got)
+	}
+}
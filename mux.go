@@ -0,0 +1,297 @@
+package tmi
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Mux presents one or more Envs, such as Clients or a Pool, as one. It
+// merges their events and fans them out to any number of subscribers, and
+// routes each command to the Env that joined the channel it is for. JOINs
+// for a new channel go to the Env with the fewest channels, other commands
+// for an unknown channel to the first Env. PINGs are answered by the Mux,
+// unless the Env answers them itself as Pool.Env does.
+type Mux struct {
+	dropped  int64 // first for 64-bit alignment of atomic operations
+	envs     []Env
+	commands chan Command
+	events   chan Event
+	queue    *eventQueue // of events not yet received on Events()
+	mu       sync.Mutex
+	reading  bool // whether Events() was called
+	subs     map[*subscription]struct{}
+	finished bool
+	owners   map[string]int // index of the Env that joined a channel
+}
+
+// Filter selects the events given to a subscriber. The zero Filter
+// selects every event.
+type Filter struct {
+	Types    []Event  // events of the same types as these, e.g. PRIVMSG{}
+	Channels []string // events in these channels, with or without '#'
+	Buffer   int      // events buffered for the subscriber, default 64
+}
+
+type subscription struct {
+	events   chan Event
+	types    map[reflect.Type]bool
+	channels map[string]bool
+}
+
+// NewMux starts multiplexing envs.
+func NewMux(envs ...Env) *Mux {
+	m := &Mux{
+		envs:     envs,
+		commands: make(chan Command),
+		events:   make(chan Event),
+		queue:    newEventQueue(),
+		subs:     make(map[*subscription]struct{}),
+		owners:   make(map[string]int),
+	}
+	go m.run()
+	go m.dispatch()
+	return m
+}
+
+// Command returns a channel routing commands to the Envs. Closing it stops
+// the routing.
+func (m *Mux) Command() chan<- Command { return m.commands }
+
+// backlog is how many events are kept for Events() before it is first
+// called, as many as a subscriber buffers by default.
+const backlog = 64
+
+// Events returns every event of the Envs. Unlike subscribers, it misses
+// none once called: events are queued without bound until received. Until
+// then, only the first 64 events not yet received are kept for it, so that
+// a Mux only used through Subscribe doesn't grow.
+func (m *Mux) Events() <-chan Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.reading {
+		m.reading = true
+		go m.pump()
+	}
+	return m.events
+}
+
+// pump moves events from the queue to Events().
+func (m *Mux) pump() {
+	defer close(m.events)
+	for {
+		ev, ok := m.queue.pop()
+		if !ok {
+			return
+		}
+		m.events <- ev
+	}
+}
+
+// Subscribe returns a channel receiving copies of the events selected by
+// filter, until cancel is called or the events of every Env end. A
+// subscriber that falls behind by more than its buffer misses events,
+// without holding up the others.
+func (m *Mux) Subscribe(filter Filter) (events <-chan Event, cancel func()) {
+	if filter.Buffer <= 0 {
+		filter.Buffer = 64
+	}
+	s := &subscription{events: make(chan Event, filter.Buffer)}
+	if len(filter.Types) > 0 {
+		s.types = make(map[reflect.Type]bool)
+		for _, ev := range filter.Types {
+			s.types[reflect.TypeOf(ev)] = true
+		}
+	}
+	if len(filter.Channels) > 0 {
+		s.channels = make(map[string]bool)
+		for _, channel := range filter.Channels {
+			s.channels[strings.ToLower(strings.TrimPrefix(channel, "#"))] = true
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.finished {
+		close(s.events)
+		return s.events, func() {}
+	}
+	m.subs[s] = struct{}{}
+	return s.events, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		if _, ok := m.subs[s]; ok {
+			delete(m.subs, s)
+			close(s.events)
+		}
+	}
+}
+
+// Dropped returns the number of events subscribers missed.
+func (m *Mux) Dropped() int64 {
+	return atomic.LoadInt64(&m.dropped)
+}
+
+// run merges the events of the Envs. Each event is fanned out before the
+// next one is received from its Env.
+func (m *Mux) run() {
+	var wg sync.WaitGroup
+	wg.Add(len(m.envs))
+	for i, env := range m.envs {
+		go func(i int, env Env) {
+			defer wg.Done()
+			_, answers := env.(pingAnswerer)
+			for ev := range env.Events() {
+				switch ev := ev.(type) {
+				case PING:
+					if !answers {
+						send(env, Pong())
+					}
+				case ROOMSTATE:
+					// Only sent to the connection that joined.
+					m.mu.Lock()
+					m.owners[ev.Channel()] = i
+					m.mu.Unlock()
+				}
+				m.fanOut(ev)
+			}
+		}(i, env)
+	}
+	wg.Wait()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for s := range m.subs {
+		delete(m.subs, s)
+		close(s.events)
+	}
+	m.finished = true
+	m.queue.close()
+}
+
+// fanOut gives an event to Events() and the subscribers selecting it.
+func (m *Mux) fanOut(ev Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.reading || m.queue.len() < backlog {
+		m.queue.push(ev)
+	}
+	for s := range m.subs {
+		if !s.match(ev) {
+			continue
+		}
+		select {
+		case s.events <- ev:
+		default:
+			atomic.AddInt64(&m.dropped, 1)
+		}
+	}
+}
+
+// logger is implemented by Envs that log diagnostics, such as a Client
+// with its Log option.
+type logger interface {
+	logln(a ...interface{})
+}
+
+// logln logs to the first Env that logs, or os.Stdout as a Client does by
+// default.
+func (m *Mux) logln(a ...interface{}) {
+	for _, env := range m.envs {
+		if l, ok := env.(logger); ok {
+			l.logln(a...)
+			return
+		}
+	}
+	fmt.Fprintln(os.Stdout, a...)
+}
+
+// pingAnswerer is implemented by Envs that answer PINGs themselves.
+type pingAnswerer interface {
+	answersPing()
+}
+
+// send a command to env. Unlike sending on Command(), sending to a closed
+// Client fails with ErrClosed rather than panicking.
+func send(env Env, command Command) error {
+	if c, ok := env.(*Client); ok {
+		return c.enqueue(command)
+	}
+	env.Command() <- command
+	return nil
+}
+
+// dispatch routes commands to the Envs until Command() is closed.
+func (m *Mux) dispatch() {
+	for command := range m.commands {
+		verb, channel, replay, err := route(command)
+		if err != nil {
+			m.logln("command failed: ", err)
+			continue
+		}
+		if len(m.envs) == 0 {
+			m.logln("command failed: no Env to send it to")
+			continue
+		}
+		if err := send(m.envs[m.owner(verb, channel)], replay); err != nil {
+			m.logln("command failed: ", err)
+		}
+	}
+}
+
+// owner returns the index of the Env to send a command to.
+func (m *Mux) owner(verb, channel string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	i, ok := m.owners[channel]
+	switch {
+	case channel == "":
+		return 0
+	case verb == "JOIN" && !ok:
+		counts := make([]int, len(m.envs))
+		for _, owner := range m.owners {
+			counts[owner]++
+		}
+		for j := range counts {
+			if counts[j] < counts[i] {
+				i = j
+			}
+		}
+		m.owners[channel] = i
+	case verb == "PART":
+		delete(m.owners, channel)
+	}
+	return i
+}
+
+func (s *subscription) match(ev Event) bool {
+	if s.types != nil && !s.types[reflect.TypeOf(ev)] {
+		return false
+	}
+	if s.channels != nil {
+		channel, ok := eventChannel(ev)
+		return ok && s.channels[channel]
+	}
+	return true
+}
+
+// eventChannel returns the channel an event happened in, if any.
+func eventChannel(ev Event) (string, bool) {
+	if change, ok := ev.(ChannelChange); ok {
+		return change.New.Name, true
+	}
+	p, ok := PacketOf(ev)
+	if !ok {
+		return "", false
+	}
+	if p.Command == "353" && len(p.Params) > 2 {
+		p.Params = p.Params[2:]
+	}
+	if len(p.Params) == 0 || !strings.HasPrefix(p.Params[0], "#") {
+		return "", false
+	}
+	return p.Params[0][1:], true
+}
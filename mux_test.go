@@ -0,0 +1,225 @@
+package tmi
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi/tmitest"
+)
+
+// fakeEnv is an Env whose events are sent by the test.
+type fakeEnv struct {
+	commands chan Command
+	events   chan Event
+}
+
+func newFakeEnv() *fakeEnv {
+	return &fakeEnv{commands: make(chan Command, 10), events: make(chan Event)}
+}
+
+func (e *fakeEnv) Command() chan<- Command { return e.commands }
+func (e *fakeEnv) Events() <-chan Event    { return e.events }
+
+func TestMuxSubscribe(t *testing.T) {
+	env := newFakeEnv()
+	m := NewMux(env)
+
+	all, _ := m.Subscribe(Filter{})
+	messages, _ := m.Subscribe(Filter{Types: []Event{PRIVMSG{}}})
+	nymn, cancel := m.Subscribe(Filter{Channels: []string{"#NymN"}})
+
+	events := []string{
+		":a!a@a.tmi.twitch.tv PRIVMSG #nymn :hi",
+		":a!a@a.tmi.twitch.tv PRIVMSG #forsen :hi",
+		":a.tmi.twitch.tv 353 a = #nymn :a b",
+		":a!a@a.tmi.twitch.tv WHISPER b :hi",
+	}
+	for _, line := range events {
+		env.events <- toevent(mustParse(t, line))
+	}
+	cancel()
+	env.events <- toevent(mustParse(t, ":a!a@a.tmi.twitch.tv PRIVMSG #nymn :bye"))
+	close(env.events)
+
+	count := func(ch <-chan Event) int {
+		var n int
+		for range ch {
+			n++
+		}
+		return n
+	}
+	if n := count(all); n != 5 {
+		t.Errorf("unfiltered subscriber got %d events, want 5", n)
+	}
+	if n := count(messages); n != 3 {
+		t.Errorf("PRIVMSG subscriber got %d events, want 3", n)
+	}
+	if n := count(nymn); n != 2 {
+		t.Errorf("#nymn subscriber got %d events, want 2 before cancel", n)
+	}
+	if n := count(m.Events()); n != 5 {
+		t.Errorf("Events() got %d events, want 5", n)
+	}
+	late, _ := m.Subscribe(Filter{})
+	if _, ok := <-late; ok {
+		t.Error("subscription after the end is open")
+	}
+}
+
+func TestMuxDropped(t *testing.T) {
+	env := newFakeEnv()
+	m := NewMux(env)
+	slow, _ := m.Subscribe(Filter{Buffer: 1})
+	for i := 0; i < 3; i++ {
+		env.events <- toevent(mustParse(t, ":a!a@a.tmi.twitch.tv PRIVMSG #nymn :hi"))
+	}
+	close(env.events)
+	for range slow {
+	}
+	if got := m.Dropped(); got != 2 {
+		t.Errorf("Dropped() = %d, want 2", got)
+	}
+}
+
+func TestMuxBacklog(t *testing.T) {
+	env := newFakeEnv()
+	m := NewMux(env)
+	all, _ := m.Subscribe(Filter{Buffer: 1})
+	send := func(n int) {
+		for i := 0; i < n; i++ {
+			env.events <- toevent(mustParse(t, ":a!a@a.tmi.twitch.tv PRIVMSG #nymn :hi"))
+			<-all
+		}
+	}
+
+	// Only used through Subscribe, the Mux keeps a bounded backlog.
+	send(2 * backlog)
+	events := m.Events()
+	send(2 * backlog)
+	close(env.events)
+	var n int
+	for range events {
+		n++
+	}
+	if want := 3 * backlog; n != want {
+		t.Errorf("Events() got %d events, want %d", n, want)
+	}
+}
+
+func TestMuxRoute(t *testing.T) {
+	a, b := newFakeEnv(), newFakeEnv()
+	m := NewMux(a, b)
+	go func() {
+		for range m.Events() {
+		}
+	}()
+
+	// received returns the line the next command sent to env writes.
+	received := func(env *fakeEnv) string {
+		t.Helper()
+		select {
+		case command := <-env.commands:
+			var buf bytes.Buffer
+			command(&buf)
+			return strings.TrimSuffix(buf.String(), Delim)
+		case <-time.After(time.Second):
+			return ""
+		}
+	}
+
+	m.Command() <- Join("one")
+	if got := received(a); got != "JOIN #one" {
+		t.Errorf("first Env got %q", got)
+	}
+	m.Command() <- Join("two")
+	if got := received(b); got != "JOIN #two" {
+		t.Errorf("second Env got %q", got)
+	}
+	m.Command() <- Say("#Two", "hi")
+	if got := received(b); got != "PRIVMSG #two :hi" {
+		t.Errorf("second Env got %q", got)
+	}
+
+	// A channel joined directly on an Env is learned from its ROOMSTATE.
+	b.events <- toevent(mustParse(t, "@slow=0 :tmi.twitch.tv ROOMSTATE #three"))
+	b.events <- toevent(mustParse(t, "PING :tmi.twitch.tv"))
+	if got := received(b); got != "PONG :tmi.twitch.tv" {
+		t.Errorf("PING not answered on its Env, got %q", got)
+	}
+	m.Command() <- Say("three", "hi")
+	if got := received(b); got != "PRIVMSG #three :hi" {
+		t.Errorf("second Env got %q", got)
+	}
+
+	m.Command() <- Whisper("someone", "hi")
+	if got := received(a); got != "PRIVMSG #jtv :/w someone hi" {
+		t.Errorf("first Env got %q", got)
+	}
+}
+
+// answeringEnv is a fakeEnv that answers PINGs itself.
+type answeringEnv struct{ *fakeEnv }
+
+func (answeringEnv) answersPing() {}
+
+func TestMuxPing(t *testing.T) {
+	env := answeringEnv{newFakeEnv()}
+	m := NewMux(env)
+	go func() {
+		for range m.Events() {
+		}
+	}()
+	env.events <- toevent(mustParse(t, "PING :tmi.twitch.tv"))
+	// Events are handled one at a time, the PING is once this is received.
+	env.events <- toevent(mustParse(t, ":a!a@a.tmi.twitch.tv PRIVMSG #nymn :hi"))
+	select {
+	case <-env.commands:
+		t.Error("PONG sent to an Env answering PINGs")
+	default:
+	}
+
+	s := tmitest.NewServer()
+	defer s.Close()
+	c, _ := NewClient(Address(s.Addr), Log(ioutil.Discard))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	c.Close()
+	if err := send(c, Pong()); err != ErrClosed {
+		t.Errorf("send() to a closed client = %v, want %v", err, ErrClosed)
+	}
+}
+
+// loggingEnv is a fakeEnv that logs to its own channel.
+type loggingEnv struct {
+	*fakeEnv
+	logged chan string
+}
+
+func (e loggingEnv) logln(a ...interface{}) {
+	e.logged <- strings.TrimSpace(fmt.Sprintln(a...))
+}
+
+func TestMuxLog(t *testing.T) {
+	env := loggingEnv{newFakeEnv(), make(chan string, 1)}
+	m := NewMux(newFakeEnv(), env)
+	go func() {
+		for range m.Events() {
+		}
+	}()
+	m.Command() <- func(io.Writer) error { return errors.New("broken") }
+	select {
+	case got := <-env.logged:
+		if !strings.Contains(got, "broken") {
+			t.Errorf("logged %q", got)
+		}
+	case <-time.After(time.Second):
+		t.Error("failed command not logged to the Env's log")
+	}
+}
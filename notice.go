@@ -0,0 +1,49 @@
+package tmi
+
+//go:generate go run mknoticeid.go
+
+// NoticeID is the msg-id tag of a NOTICE.
+type NoticeID string
+
+// NoticeCategory groups NoticeIDs by what they mean for the client.
+type NoticeCategory int
+
+const (
+	UnknownNotice   NoticeCategory = iota // Not a documented msg-id.
+	RejectionNotice                       // A chat message was not delivered.
+	ErrorNotice                           // A command failed.
+	StateNotice                           // The channel's chat settings changed.
+	InfoNotice                            // A command succeeded, or informational text.
+)
+
+func (c NoticeCategory) String() string {
+	switch c {
+	case RejectionNotice:
+		return "rejection"
+	case ErrorNotice:
+		return "error"
+	case StateNotice:
+		return "state change"
+	case InfoNotice:
+		return "info"
+	default:
+		return "unknown"
+	}
+}
+
+// Category of the msg-id.
+func (id NoticeID) Category() NoticeCategory { return noticeCategories[id] }
+
+// IsRejection reports whether the notice means a chat message was not
+// delivered, e.g. msg_duplicate or msg_banned.
+func (id NoticeID) IsRejection() bool { return id.Category() == RejectionNotice }
+
+// IsError reports whether the notice means a message or command failed.
+func (id NoticeID) IsError() bool {
+	category := id.Category()
+	return category == RejectionNotice || category == ErrorNotice
+}
+
+// IsStateChange reports whether the notice announces a change of the
+// channel's chat settings, e.g. slow_on.
+func (id NoticeID) IsStateChange() bool { return id.Category() == StateNotice }
@@ -0,0 +1,46 @@
+package tmi
+
+import "testing"
+
+func TestNoticeID(t *testing.T) {
+	tests := []struct {
+		id        NoticeID
+		category  NoticeCategory
+		rejection bool
+		error     bool
+	}{
+		{NoticeMsgDuplicate, RejectionNotice, true, true},
+		{NoticeNoPermission, ErrorNotice, false, true},
+		{NoticeSlowOn, StateNotice, false, false},
+		{NoticeBanSuccess, InfoNotice, false, false},
+		{"not_a_notice", UnknownNotice, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.id), func(t *testing.T) {
+			if got := tt.id.Category(); got != tt.category {
+				t.Errorf("Category() = %v, want %v", got, tt.category)
+			}
+			if got := tt.id.IsRejection(); got != tt.rejection {
+				t.Errorf("IsRejection() = %v, want %v", got, tt.rejection)
+			}
+			if got := tt.id.IsError(); got != tt.error {
+				t.Errorf("IsError() = %v, want %v", got, tt.error)
+			}
+		})
+	}
+}
+
+func TestNOTICE(t *testing.T) {
+	ev := toevent(mustParse(t, "@msg-id=slow_on :tmi.twitch.tv NOTICE #bar :This room is now in slow mode.")).(NOTICE)
+	if id, err := ev.MsgID(); err != nil || id != NoticeSlowOn {
+		t.Errorf("MsgID() = %q, %v", id, err)
+	}
+	if ev.Global() || ev.Channel() != "bar" {
+		t.Errorf("Channel() = %q, Global() = %v", ev.Channel(), ev.Global())
+	}
+
+	ev = toevent(mustParse(t, ":tmi.twitch.tv NOTICE * :Login authentication failed")).(NOTICE)
+	if !ev.Global() || ev.Channel() != "" {
+		t.Errorf("Channel() = %q, Global() = %v", ev.Channel(), ev.Global())
+	}
+}
@@ -0,0 +1,396 @@
+// Code generated by mknoticeid.go; DO NOT EDIT.
+
+package tmi
+
+const (
+	// <user> is already banned in this channel.
+	NoticeAlreadyBanned NoticeID = "already_banned"
+	// This room is not in emote-only mode.
+	NoticeAlreadyEmoteOnlyOff NoticeID = "already_emote_only_off"
+	// This room is already in emote-only mode.
+	NoticeAlreadyEmoteOnlyOn NoticeID = "already_emote_only_on"
+	// This room is not in followers-only mode.
+	NoticeAlreadyFollowersOff NoticeID = "already_followers_off"
+	// This room is already in <duration> followers-only mode.
+	NoticeAlreadyFollowersOn NoticeID = "already_followers_on"
+	// This room is not in unique-chat mode.
+	NoticeAlreadyR9kOff NoticeID = "already_r9k_off"
+	// This room is already in unique-chat mode.
+	NoticeAlreadyR9kOn NoticeID = "already_r9k_on"
+	// This room is not in slow mode.
+	NoticeAlreadySlowOff NoticeID = "already_slow_off"
+	// This room is already in <duration>-second slow mode.
+	NoticeAlreadySlowOn NoticeID = "already_slow_on"
+	// This room is not in subscribers-only mode.
+	NoticeAlreadySubsOff NoticeID = "already_subs_off"
+	// This room is already in subscribers-only mode.
+	NoticeAlreadySubsOn NoticeID = "already_subs_on"
+	// You cannot ban admin <user>.
+	NoticeBadBanAdmin NoticeID = "bad_ban_admin"
+	// You cannot ban anonymous users.
+	NoticeBadBanAnon NoticeID = "bad_ban_anon"
+	// You cannot ban the broadcaster.
+	NoticeBadBanBroadcaster NoticeID = "bad_ban_broadcaster"
+	// You cannot ban moderator <user> unless you are the owner of this channel.
+	NoticeBadBanMod NoticeID = "bad_ban_mod"
+	// You cannot ban yourself.
+	NoticeBadBanSelf NoticeID = "bad_ban_self"
+	// You cannot ban staff <user>.
+	NoticeBadBanStaff NoticeID = "bad_ban_staff"
+	// Failed to start commercial.
+	NoticeBadCommercialError NoticeID = "bad_commercial_error"
+	// You cannot delete the broadcaster's messages.
+	NoticeBadDeleteMessageBroadcaster NoticeID = "bad_delete_message_broadcaster"
+	// You cannot delete messages from another moderator <user>.
+	NoticeBadDeleteMessageMod NoticeID = "bad_delete_message_mod"
+	// There was a problem hosting <channel>. Please try again in a minute.
+	NoticeBadHostError NoticeID = "bad_host_error"
+	// This channel is already hosting <channel>.
+	NoticeBadHostHosting NoticeID = "bad_host_hosting"
+	// Host target cannot be changed more than <number> times every half hour.
+	NoticeBadHostRateExceeded NoticeID = "bad_host_rate_exceeded"
+	// This channel is unable to be hosted.
+	NoticeBadHostRejected NoticeID = "bad_host_rejected"
+	// A channel cannot host itself.
+	NoticeBadHostSelf NoticeID = "bad_host_self"
+	// <user> is banned in this channel and must be unbanned before they can be modded.
+	NoticeBadModBanned NoticeID = "bad_mod_banned"
+	// <user> is already a moderator of this channel.
+	NoticeBadModMod NoticeID = "bad_mod_mod"
+	// You cannot set slow delay to more than <number> seconds.
+	NoticeBadSlowDuration NoticeID = "bad_slow_duration"
+	// You cannot timeout admin <user>.
+	NoticeBadTimeoutAdmin NoticeID = "bad_timeout_admin"
+	// You cannot timeout anonymous users.
+	NoticeBadTimeoutAnon NoticeID = "bad_timeout_anon"
+	// You cannot timeout the broadcaster.
+	NoticeBadTimeoutBroadcaster NoticeID = "bad_timeout_broadcaster"
+	// You cannot time a user out for more than <seconds>.
+	NoticeBadTimeoutDuration NoticeID = "bad_timeout_duration"
+	// You cannot timeout moderator <user> unless you are the owner of this channel.
+	NoticeBadTimeoutMod NoticeID = "bad_timeout_mod"
+	// You cannot timeout yourself.
+	NoticeBadTimeoutSelf NoticeID = "bad_timeout_self"
+	// You cannot timeout staff <user>.
+	NoticeBadTimeoutStaff NoticeID = "bad_timeout_staff"
+	// <user> is not banned from this channel.
+	NoticeBadUnbanNoBan NoticeID = "bad_unban_no_ban"
+	// There was a problem exiting host mode. Please try again in a minute.
+	NoticeBadUnhostError NoticeID = "bad_unhost_error"
+	// <user> is not a moderator of this channel.
+	NoticeBadUnmodMod NoticeID = "bad_unmod_mod"
+	// <user> is not a VIP of this channel.
+	NoticeBadUnvipGranteeNotVip NoticeID = "bad_unvip_grantee_not_vip"
+	// Unable to add VIP. Visit the Achievements page on your dashboard to learn how to unlock this feature.
+	NoticeBadVipAchievementIncomplete NoticeID = "bad_vip_achievement_incomplete"
+	// <user> is already a VIP of this channel.
+	NoticeBadVipGranteeAlreadyVip NoticeID = "bad_vip_grantee_already_vip"
+	// <user> is banned in this channel and must be unbanned before they can be VIPed.
+	NoticeBadVipGranteeBanned NoticeID = "bad_vip_grantee_banned"
+	// Unable to add VIP. Visit the Achievements page on your dashboard to learn how to unlock additional VIP slots.
+	NoticeBadVipMaxVipsReached NoticeID = "bad_vip_max_vips_reached"
+	// <user> is now banned from this channel.
+	NoticeBanSuccess NoticeID = "ban_success"
+	// Commands available to you in this room.
+	NoticeCmdsAvailable NoticeID = "cmds_available"
+	// Your color has been changed.
+	NoticeColorChanged NoticeID = "color_changed"
+	// Initiating <number> second commercial break.
+	NoticeCommercialSuccess NoticeID = "commercial_success"
+	// The message from <user> is now deleted.
+	NoticeDeleteMessageSuccess NoticeID = "delete_message_success"
+	// This room is no longer in emote-only mode.
+	NoticeEmoteOnlyOff NoticeID = "emote_only_off"
+	// This room is now in emote-only mode.
+	NoticeEmoteOnlyOn NoticeID = "emote_only_on"
+	// This room is no longer in followers-only mode.
+	NoticeFollowersOff NoticeID = "followers_off"
+	// This room is now in <duration> followers-only mode.
+	NoticeFollowersOn NoticeID = "followers_on"
+	// This room is now in followers-only mode.
+	NoticeFollowersOnZero NoticeID = "followers_on_zero"
+	// Exited host mode.
+	NoticeHostOff NoticeID = "host_off"
+	// Now hosting <channel>.
+	NoticeHostOn NoticeID = "host_on"
+	// <user> is now hosting you.
+	NoticeHostSuccess NoticeID = "host_success"
+	// <user> is now hosting you for up to <number> viewers.
+	NoticeHostSuccessViewers NoticeID = "host_success_viewers"
+	// <channel> has gone offline. Exiting host mode.
+	NoticeHostTargetWentOffline NoticeID = "host_target_went_offline"
+	// <number> host commands remaining this half hour.
+	NoticeHostsRemaining NoticeID = "hosts_remaining"
+	// Invalid username: <user>
+	NoticeInvalidUser NoticeID = "invalid_user"
+	// You have added <user> as a moderator of this channel.
+	NoticeModSuccess NoticeID = "mod_success"
+	// Your message was not sent because it contained too many unprocessable characters.
+	NoticeMsgBadCharacters NoticeID = "msg_bad_characters"
+	// You are permanently banned from talking in <channel>.
+	NoticeMsgBanned NoticeID = "msg_banned"
+	// Your message was not sent because your account is not in good standing in this channel.
+	NoticeMsgChannelBlocked NoticeID = "msg_channel_blocked"
+	// This channel does not exist or has been suspended.
+	NoticeMsgChannelSuspended NoticeID = "msg_channel_suspended"
+	// Your message was not sent because it is identical to the previous one you sent, less than 30 seconds ago.
+	NoticeMsgDuplicate NoticeID = "msg_duplicate"
+	// This room is in emote-only mode.
+	NoticeMsgEmoteonly NoticeID = "msg_emoteonly"
+	// This room is in <duration> followers-only mode.
+	NoticeMsgFollowersonly NoticeID = "msg_followersonly"
+	// This room is in <duration1> followers-only mode. You have been following for <duration2>.
+	NoticeMsgFollowersonlyFollowed NoticeID = "msg_followersonly_followed"
+	// This room is in followers-only mode.
+	NoticeMsgFollowersonlyZero NoticeID = "msg_followersonly_zero"
+	// This room is in unique-chat mode and the message you attempted to send is not unique.
+	NoticeMsgR9k NoticeID = "msg_r9k"
+	// Your message was not sent because you are sending messages too quickly.
+	NoticeMsgRatelimit NoticeID = "msg_ratelimit"
+	// Hey! Your message is being checked by mods and has not been sent.
+	NoticeMsgRejected NoticeID = "msg_rejected"
+	// Your message wasn't posted due to conflicts with the channel's moderation settings.
+	NoticeMsgRejectedMandatory NoticeID = "msg_rejected_mandatory"
+	// A verified phone number is required to chat in this channel.
+	NoticeMsgRequiresVerifiedPhoneNumber NoticeID = "msg_requires_verified_phone_number"
+	// This room is in slow mode and you are sending messages too quickly.
+	NoticeMsgSlowmode NoticeID = "msg_slowmode"
+	// This room is in subscribers only mode.
+	NoticeMsgSubsonly NoticeID = "msg_subsonly"
+	// You don't have permission to perform that action.
+	NoticeMsgSuspended NoticeID = "msg_suspended"
+	// You are timed out for <number> more seconds.
+	NoticeMsgTimedout NoticeID = "msg_timedout"
+	// This room requires a verified account to chat.
+	NoticeMsgVerifiedEmail NoticeID = "msg_verified_email"
+	// No help available.
+	NoticeNoHelp NoticeID = "no_help"
+	// There are no moderators of this channel.
+	NoticeNoMods NoticeID = "no_mods"
+	// You don't have permission to perform that action.
+	NoticeNoPermission NoticeID = "no_permission"
+	// This channel does not have any VIPs.
+	NoticeNoVips NoticeID = "no_vips"
+	// No channel is currently being hosted.
+	NoticeNotHosting NoticeID = "not_hosting"
+	// This room is no longer in unique-chat mode.
+	NoticeR9kOff NoticeID = "r9k_off"
+	// This room is now in unique-chat mode.
+	NoticeR9kOn NoticeID = "r9k_on"
+	// You already have a raid in progress.
+	NoticeRaidErrorAlreadyRaiding NoticeID = "raid_error_already_raiding"
+	// You cannot raid this channel.
+	NoticeRaidErrorForbidden NoticeID = "raid_error_forbidden"
+	// A channel cannot raid itself.
+	NoticeRaidErrorSelf NoticeID = "raid_error_self"
+	// Sorry, you have more viewers than the maximum currently supported by raids right now.
+	NoticeRaidErrorTooManyViewers NoticeID = "raid_error_too_many_viewers"
+	// There was a problem raiding <channel>. Please try again in a minute.
+	NoticeRaidErrorUnexpected NoticeID = "raid_error_unexpected"
+	// This channel is intended for mature audiences.
+	NoticeRaidNoticeMature NoticeID = "raid_notice_mature"
+	// This channel has follower- or subscriber-only chat.
+	NoticeRaidNoticeRestrictedChat NoticeID = "raid_notice_restricted_chat"
+	// The moderators of this channel are: <list of users>
+	NoticeRoomMods NoticeID = "room_mods"
+	// This room is no longer in slow mode.
+	NoticeSlowOff NoticeID = "slow_off"
+	// This room is now in slow mode. You may send messages every <number> seconds.
+	NoticeSlowOn NoticeID = "slow_on"
+	// This room is no longer in subscribers-only mode.
+	NoticeSubsOff NoticeID = "subs_off"
+	// This room is now in subscribers-only mode.
+	NoticeSubsOn NoticeID = "subs_on"
+	// <user> is not timed out from this channel.
+	NoticeTimeoutNoTimeout NoticeID = "timeout_no_timeout"
+	// <user> has been timed out for <duration>.
+	NoticeTimeoutSuccess NoticeID = "timeout_success"
+	// The community has closed channel <channel> due to Terms of Service violations.
+	NoticeTosBan NoticeID = "tos_ban"
+	// Only turbo users can specify an arbitrary hex color.
+	NoticeTurboOnlyColor NoticeID = "turbo_only_color"
+	// Sorry, "<command>" is not available through this client.
+	NoticeUnavailableCommand NoticeID = "unavailable_command"
+	// <user> is no longer banned from this channel.
+	NoticeUnbanSuccess NoticeID = "unban_success"
+	// You have removed <user> as a moderator of this channel.
+	NoticeUnmodSuccess NoticeID = "unmod_success"
+	// You do not have an active raid.
+	NoticeUnraidErrorNoActiveRaid NoticeID = "unraid_error_no_active_raid"
+	// There was a problem stopping the raid. Please try again in a minute.
+	NoticeUnraidErrorUnexpected NoticeID = "unraid_error_unexpected"
+	// The raid has been canceled.
+	NoticeUnraidSuccess NoticeID = "unraid_success"
+	// Unrecognized command: <command>
+	NoticeUnrecognizedCmd NoticeID = "unrecognized_cmd"
+	// <user> is permanently banned.
+	NoticeUntimeoutBanned NoticeID = "untimeout_banned"
+	// <user> is no longer timed out in this channel.
+	NoticeUntimeoutSuccess NoticeID = "untimeout_success"
+	// You have removed <user> as a VIP of this channel.
+	NoticeUnvipSuccess NoticeID = "unvip_success"
+	// Usage: "/ban <username> [reason]"
+	NoticeUsageBan NoticeID = "usage_ban"
+	// Usage: "/clear" - Clear chat history for all users in this room.
+	NoticeUsageClear NoticeID = "usage_clear"
+	// Usage: "/delete <msg id>" - Deletes the specified message.
+	NoticeUsageDelete NoticeID = "usage_delete"
+	// Usage: "/timeout <username> [duration][time unit] [reason]"
+	NoticeUsageTimeout NoticeID = "usage_timeout"
+	// Usage: "/unban <username>"
+	NoticeUsageUnban NoticeID = "usage_unban"
+	// Usage: "/untimeout <username>"
+	NoticeUsageUntimeout NoticeID = "usage_untimeout"
+	// You have added <user> as a VIP of this channel.
+	NoticeVipSuccess NoticeID = "vip_success"
+	// The VIPs of this channel are: <list of users>.
+	NoticeVipsSuccess NoticeID = "vips_success"
+	// You have been banned from sending whispers.
+	NoticeWhisperBanned NoticeID = "whisper_banned"
+	// That user has been banned from receiving whispers.
+	NoticeWhisperBannedRecipient NoticeID = "whisper_banned_recipient"
+	// Usage: <login> <message>
+	NoticeWhisperInvalidLogin NoticeID = "whisper_invalid_login"
+	// You cannot whisper to yourself.
+	NoticeWhisperInvalidSelf NoticeID = "whisper_invalid_self"
+	// You are sending whispers too fast. Try again in a minute.
+	NoticeWhisperLimitPerMin NoticeID = "whisper_limit_per_min"
+	// You are sending whispers too fast. Try again in a second.
+	NoticeWhisperLimitPerSec NoticeID = "whisper_limit_per_sec"
+	// Your settings prevent you from sending this whisper.
+	NoticeWhisperRestricted NoticeID = "whisper_restricted"
+	// That user's settings prevent them from receiving this whisper.
+	NoticeWhisperRestrictedRecipient NoticeID = "whisper_restricted_recipient"
+)
+
+var noticeCategories = map[NoticeID]NoticeCategory{
+	NoticeAlreadyBanned:                  ErrorNotice,
+	NoticeAlreadyEmoteOnlyOff:            ErrorNotice,
+	NoticeAlreadyEmoteOnlyOn:             ErrorNotice,
+	NoticeAlreadyFollowersOff:            ErrorNotice,
+	NoticeAlreadyFollowersOn:             ErrorNotice,
+	NoticeAlreadyR9kOff:                  ErrorNotice,
+	NoticeAlreadyR9kOn:                   ErrorNotice,
+	NoticeAlreadySlowOff:                 ErrorNotice,
+	NoticeAlreadySlowOn:                  ErrorNotice,
+	NoticeAlreadySubsOff:                 ErrorNotice,
+	NoticeAlreadySubsOn:                  ErrorNotice,
+	NoticeBadBanAdmin:                    ErrorNotice,
+	NoticeBadBanAnon:                     ErrorNotice,
+	NoticeBadBanBroadcaster:              ErrorNotice,
+	NoticeBadBanMod:                      ErrorNotice,
+	NoticeBadBanSelf:                     ErrorNotice,
+	NoticeBadBanStaff:                    ErrorNotice,
+	NoticeBadCommercialError:             ErrorNotice,
+	NoticeBadDeleteMessageBroadcaster:    ErrorNotice,
+	NoticeBadDeleteMessageMod:            ErrorNotice,
+	NoticeBadHostError:                   ErrorNotice,
+	NoticeBadHostHosting:                 ErrorNotice,
+	NoticeBadHostRateExceeded:            ErrorNotice,
+	NoticeBadHostRejected:                ErrorNotice,
+	NoticeBadHostSelf:                    ErrorNotice,
+	NoticeBadModBanned:                   ErrorNotice,
+	NoticeBadModMod:                      ErrorNotice,
+	NoticeBadSlowDuration:                ErrorNotice,
+	NoticeBadTimeoutAdmin:                ErrorNotice,
+	NoticeBadTimeoutAnon:                 ErrorNotice,
+	NoticeBadTimeoutBroadcaster:          ErrorNotice,
+	NoticeBadTimeoutDuration:             ErrorNotice,
+	NoticeBadTimeoutMod:                  ErrorNotice,
+	NoticeBadTimeoutSelf:                 ErrorNotice,
+	NoticeBadTimeoutStaff:                ErrorNotice,
+	NoticeBadUnbanNoBan:                  ErrorNotice,
+	NoticeBadUnhostError:                 ErrorNotice,
+	NoticeBadUnmodMod:                    ErrorNotice,
+	NoticeBadUnvipGranteeNotVip:          ErrorNotice,
+	NoticeBadVipAchievementIncomplete:    ErrorNotice,
+	NoticeBadVipGranteeAlreadyVip:        ErrorNotice,
+	NoticeBadVipGranteeBanned:            ErrorNotice,
+	NoticeBadVipMaxVipsReached:           ErrorNotice,
+	NoticeBanSuccess:                     InfoNotice,
+	NoticeCmdsAvailable:                  InfoNotice,
+	NoticeColorChanged:                   InfoNotice,
+	NoticeCommercialSuccess:              InfoNotice,
+	NoticeDeleteMessageSuccess:           InfoNotice,
+	NoticeEmoteOnlyOff:                   StateNotice,
+	NoticeEmoteOnlyOn:                    StateNotice,
+	NoticeFollowersOff:                   StateNotice,
+	NoticeFollowersOn:                    StateNotice,
+	NoticeFollowersOnZero:                StateNotice,
+	NoticeHostOff:                        StateNotice,
+	NoticeHostOn:                         StateNotice,
+	NoticeHostSuccess:                    InfoNotice,
+	NoticeHostSuccessViewers:             InfoNotice,
+	NoticeHostTargetWentOffline:          StateNotice,
+	NoticeHostsRemaining:                 InfoNotice,
+	NoticeInvalidUser:                    ErrorNotice,
+	NoticeModSuccess:                     InfoNotice,
+	NoticeMsgBadCharacters:               RejectionNotice,
+	NoticeMsgBanned:                      RejectionNotice,
+	NoticeMsgChannelBlocked:              RejectionNotice,
+	NoticeMsgChannelSuspended:            RejectionNotice,
+	NoticeMsgDuplicate:                   RejectionNotice,
+	NoticeMsgEmoteonly:                   RejectionNotice,
+	NoticeMsgFollowersonly:               RejectionNotice,
+	NoticeMsgFollowersonlyFollowed:       RejectionNotice,
+	NoticeMsgFollowersonlyZero:           RejectionNotice,
+	NoticeMsgR9k:                         RejectionNotice,
+	NoticeMsgRatelimit:                   RejectionNotice,
+	NoticeMsgRejected:                    RejectionNotice,
+	NoticeMsgRejectedMandatory:           RejectionNotice,
+	NoticeMsgRequiresVerifiedPhoneNumber: RejectionNotice,
+	NoticeMsgSlowmode:                    RejectionNotice,
+	NoticeMsgSubsonly:                    RejectionNotice,
+	NoticeMsgSuspended:                   RejectionNotice,
+	NoticeMsgTimedout:                    RejectionNotice,
+	NoticeMsgVerifiedEmail:               RejectionNotice,
+	NoticeNoHelp:                         ErrorNotice,
+	NoticeNoMods:                         InfoNotice,
+	NoticeNoPermission:                   ErrorNotice,
+	NoticeNoVips:                         InfoNotice,
+	NoticeNotHosting:                     ErrorNotice,
+	NoticeR9kOff:                         StateNotice,
+	NoticeR9kOn:                          StateNotice,
+	NoticeRaidErrorAlreadyRaiding:        ErrorNotice,
+	NoticeRaidErrorForbidden:             ErrorNotice,
+	NoticeRaidErrorSelf:                  ErrorNotice,
+	NoticeRaidErrorTooManyViewers:        ErrorNotice,
+	NoticeRaidErrorUnexpected:            ErrorNotice,
+	NoticeRaidNoticeMature:               InfoNotice,
+	NoticeRaidNoticeRestrictedChat:       InfoNotice,
+	NoticeRoomMods:                       InfoNotice,
+	NoticeSlowOff:                        StateNotice,
+	NoticeSlowOn:                         StateNotice,
+	NoticeSubsOff:                        StateNotice,
+	NoticeSubsOn:                         StateNotice,
+	NoticeTimeoutNoTimeout:               ErrorNotice,
+	NoticeTimeoutSuccess:                 InfoNotice,
+	NoticeTosBan:                         ErrorNotice,
+	NoticeTurboOnlyColor:                 ErrorNotice,
+	NoticeUnavailableCommand:             ErrorNotice,
+	NoticeUnbanSuccess:                   InfoNotice,
+	NoticeUnmodSuccess:                   InfoNotice,
+	NoticeUnraidErrorNoActiveRaid:        ErrorNotice,
+	NoticeUnraidErrorUnexpected:          ErrorNotice,
+	NoticeUnraidSuccess:                  InfoNotice,
+	NoticeUnrecognizedCmd:                ErrorNotice,
+	NoticeUntimeoutBanned:                ErrorNotice,
+	NoticeUntimeoutSuccess:               InfoNotice,
+	NoticeUnvipSuccess:                   InfoNotice,
+	NoticeUsageBan:                       ErrorNotice,
+	NoticeUsageClear:                     ErrorNotice,
+	NoticeUsageDelete:                    ErrorNotice,
+	NoticeUsageTimeout:                   ErrorNotice,
+	NoticeUsageUnban:                     ErrorNotice,
+	NoticeUsageUntimeout:                 ErrorNotice,
+	NoticeVipSuccess:                     InfoNotice,
+	NoticeVipsSuccess:                    InfoNotice,
+	NoticeWhisperBanned:                  ErrorNotice,
+	NoticeWhisperBannedRecipient:         ErrorNotice,
+	NoticeWhisperInvalidLogin:            ErrorNotice,
+	NoticeWhisperInvalidSelf:             ErrorNotice,
+	NoticeWhisperLimitPerMin:             ErrorNotice,
+	NoticeWhisperLimitPerSec:             ErrorNotice,
+	NoticeWhisperRestricted:              ErrorNotice,
+	NoticeWhisperRestrictedRecipient:     ErrorNotice,
+}
@@ -1,9 +1,36 @@
 package tmi
 
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"time"
+)
+
 type Option func(*Client)
 
 func SSL(c *Client) error { return nil }
 
+// Address sets the host:port of the server to connect to, by default
+// Twitch's plain text IRC endpoint. With WebSocket, it is a ws:// or
+// wss:// URL instead.
+func Address(addr string) Option {
+	return func(c *Client) {
+		c.addr = addr
+	}
+}
+
+// Dialer sets the function used to connect to the server, for example to
+// go through a proxy, bind a local address, or use TLS. The default is a
+// plain net.Dialer.
+func Dialer(dial func(ctx context.Context, network, addr string) (net.Conn, error)) Option {
+	return func(c *Client) {
+		c.dial = dial
+	}
+}
+
 func Auth(nick, pass string) Option {
 	return func(c *Client) {
 		c.nick, c.pass = nick, pass
@@ -24,3 +51,87 @@ func Cap(caps ...string) Option {
 		c.capabilities = append(c.capabilities, caps...)
 	}
 }
+
+// RateLimit limits outgoing messages to n per duration. Messages exceeding
+// the limit are rejected with ErrRateLimited. Twitch's limit for regular
+// users is 20 per 30 seconds. By default messages are not limited; n <= 0
+// disables the limiter.
+func RateLimit(n int, per time.Duration) Option {
+	return func(c *Client) {
+		if n <= 0 {
+			c.limiter = nil
+			return
+		}
+		c.limiter = newLimiter(n, per)
+	}
+}
+
+// WhisperRateLimit limits outgoing whispers to perSecond and perMinute, and
+// to whispering at most recipients distinct users per day. Whispers
+// exceeding the limits are rejected with ErrRateLimited. The default is 3
+// per second, 100 per minute and 40 recipients; perSecond <= 0 disables
+// the limiter.
+func WhisperRateLimit(perSecond, perMinute, recipients int) Option {
+	return func(c *Client) {
+		if perSecond <= 0 {
+			c.whispers = nil
+			return
+		}
+		c.whispers = newWhisperLimiter(perSecond, perMinute, recipients)
+	}
+}
+
+// BypassDuplicates makes repeated messages get past Twitch's duplicate
+// message filter, which drops a message identical to the previous one sent
+// to the same channel within 30 seconds. The repeat is made unique by
+// appending an invisible character.
+func BypassDuplicates(c *Client) {
+	c.bypassDuplicates = true
+}
+
+// Track registers handlers, such as a Presence, to be given every event.
+func Track(handlers ...Handler) Option {
+	return func(c *Client) {
+		c.handlers = append(c.handlers, handlers...)
+	}
+}
+
+// ModerateWith makes Client.Moderate perform actions through m instead of
+// chat commands.
+func ModerateWith(m Moderator) Option {
+	return func(c *Client) {
+		c.moderator = m
+	}
+}
+
+// SplitConnections makes the client receive chat on a separate anonymous
+// connection, and use the authenticated one only for sending and for what
+// is addressed to the user: USERSTATE, NOTICE and WHISPER. Joins and parts
+// are sent on both, and PINGs are answered by the client on the connection
+// that received them instead of being delivered. If either connection
+// drops, the client disconnects.
+func SplitConnections(c *Client) {
+	c.split = true
+}
+
+// Log writes the client's traffic and diagnostics to w, by default
+// os.Stdout. Use ioutil.Discard to silence them. A Pool, and a Mux of
+// Clients or a Pool, log their failures there too.
+func Log(w io.Writer) Option {
+	return func(c *Client) {
+		c.log = w
+	}
+}
+
+// logOf returns the writer options make clients log to.
+func logOf(options []Option) io.Writer {
+	c := Client{log: os.Stdout}
+	for _, option := range options {
+		option(&c)
+	}
+	return c.log
+}
+
+func (c *Client) logln(a ...interface{}) {
+	fmt.Fprintln(c.log, a...)
+}
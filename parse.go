@@ -2,6 +2,8 @@ package tmi
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 )
 
 func isLetter(r int) bool {
@@ -272,3 +274,82 @@ next:
 
 	return packet, nil
 }
+
+// String formats the packet as an IRC line, without the trailing CRLF.
+func (p Packet) String() string {
+	var b strings.Builder
+	if p.Tags != nil {
+		keys := make([]string, 0, len(p.Tags))
+		for key := range p.Tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		b.WriteByte('@')
+		for i, key := range keys {
+			if i > 0 {
+				b.WriteByte(';')
+			}
+			b.WriteString(key)
+			b.WriteByte('=')
+			b.WriteString(p.Tags[key])
+		}
+		b.WriteByte(' ')
+	}
+	if p.Prefix.Nick != "" || p.Prefix.Host != "" {
+		b.WriteByte(':')
+		if p.Prefix.Nick != "" {
+			b.WriteString(p.Prefix.Nick)
+			if p.Prefix.User != "" {
+				b.WriteByte('!')
+				b.WriteString(p.Prefix.User)
+			}
+			if p.Prefix.Host != "" {
+				b.WriteByte('@')
+			}
+		}
+		b.WriteString(p.Prefix.Host)
+		b.WriteByte(' ')
+	}
+	b.WriteString(p.Command)
+	for i, param := range p.Params {
+		b.WriteByte(' ')
+		if i == len(p.Params)-1 && (param == "" || param[0] == ':' || strings.Contains(param, " ")) {
+			b.WriteByte(':')
+		}
+		b.WriteString(param)
+	}
+	return b.String()
+}
+
+// UnescapeTag decodes an IRCv3 tag value, such as the "\s" standing for a
+// space in the system-msg of a USERNOTICE.
+func UnescapeTag(value string) string {
+	if !strings.Contains(value, `\`) {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		if i == len(value) {
+			// A trailing backslash is dropped.
+			break
+		}
+		switch value[i] {
+		case ':':
+			b.WriteByte(';')
+		case 's':
+			b.WriteByte(' ')
+		case 'r':
+			b.WriteByte('\r')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
@@ -193,3 +193,43 @@ func Test_parsePacket(t *testing.T) {
 		})
 	}
 }
+
+func TestPacket_String(t *testing.T) {
+	tests := []string{
+		"PRIVMSG",
+		"PRIVMSG #nymn :nobody knows",
+		"PRIVMSG #nymn :",
+		":tmi.twitch.tv 421 justinfan64537 WHO :Unknown command",
+		"@badges=staff/1;color=#0D4200 :tmi.twitch.tv USERSTATE #dallas",
+		":justinfan64537!justinfan64537@justinfan64537.tmi.twitch.tv JOIN #nymn",
+	}
+	for _, line := range tests {
+		t.Run(line, func(t *testing.T) {
+			p, err := parsePacket([]byte(line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != line {
+				t.Errorf("String() = %q, want %q", got, line)
+			}
+		})
+	}
+}
+
+func TestUnescapeTag(t *testing.T) {
+	tests := []struct {
+		value, want string
+	}{
+		{"plain", "plain"},
+		{`15\sraiders\sfrom\sTestChannel`, "15 raiders from TestChannel"},
+		{`a\:b\\c`, `a;b\c`},
+		{`line\r\n`, "line\r\n"},
+		{`unknown\x`, "unknownx"},
+		{`trailing\`, "trailing"},
+	}
+	for _, tt := range tests {
+		if got := UnescapeTag(tt.value); got != tt.want {
+			t.Errorf("UnescapeTag(%q) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
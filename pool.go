@@ -0,0 +1,421 @@
+package tmi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ShardEvent is an event received by one of a Pool's connections.
+type ShardEvent struct {
+	Shard int
+	Event Event
+}
+
+// RejoinError is delivered as the Event of a ShardEvent when channels of a
+// lost shard could not be joined again.
+type RejoinError struct {
+	Channels []string
+	Err      error
+}
+
+func (e *RejoinError) Error() string {
+	return fmt.Sprintf("tmi: failed to rejoin #%s: %v", strings.Join(e.Channels, ", #"), e.Err)
+}
+
+// Pool spreads channels over as many connections as needed to keep each
+// below a number of channels, and presents them as one. Connections that
+// drop are replaced and their channels joined again.
+type Pool struct {
+	perConn    int
+	options    []Option
+	events     chan ShardEvent
+	log        io.Writer
+	done       chan struct{} // closed by Close
+	forwarders sync.WaitGroup
+
+	mu       sync.Mutex
+	next     int // ID of the next shard
+	shards   []*shard
+	channels map[string]*shard
+	closed   bool
+}
+
+type shard struct {
+	id       int
+	client   *Client
+	channels map[string]bool
+	joins    *limiter
+}
+
+// NewPool creates a pool of connections with at most channelsPerConn
+// channels each, made with NewClient(options...).
+func NewPool(channelsPerConn int, options ...Option) *Pool {
+	p := &Pool{
+		perConn:  channelsPerConn,
+		options:  options,
+		events:   make(chan ShardEvent),
+		log:      logOf(options),
+		done:     make(chan struct{}),
+		channels: make(map[string]*shard),
+	}
+	go func() {
+		<-p.done
+		p.forwarders.Wait()
+		close(p.events)
+	}()
+	return p
+}
+
+// Events of every connection in the pool, closed once the pool is closed.
+// PINGs are answered by the pool.
+func (p *Pool) Events() <-chan ShardEvent { return p.events }
+
+// Join channels, connecting more shards as needed. Joins are limited to
+// Twitch's 20 per 10 seconds per connection, so Join may block for a while.
+func (p *Pool) Join(ctx context.Context, channels ...string) error {
+	for _, channel := range channels {
+		name, err := normalizeChannel(channel)
+		if err != nil {
+			return err
+		}
+		s, err := p.assign(name)
+		if err != nil {
+			return err
+		}
+		if s == nil {
+			// Already joined.
+			continue
+		}
+		if err := s.join(ctx, name); err != nil {
+			p.unassign(s, name)
+			return err
+		}
+	}
+	return nil
+}
+
+// Part a channel.
+func (p *Pool) Part(ctx context.Context, channel string) error {
+	name, err := normalizeChannel(channel)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	s, ok := p.channels[name]
+	if ok {
+		delete(p.channels, name)
+		delete(s.channels, name)
+	}
+	p.mu.Unlock()
+	if !ok {
+		return nil
+	}
+	return s.client.Send(ctx, Part(name))
+}
+
+// Shard returns the ID of the shard a channel is joined on.
+func (p *Pool) Shard(channel string) (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s, ok := p.channels[strings.ToLower(strings.TrimPrefix(channel, "#"))]
+	if !ok {
+		return 0, false
+	}
+	return s.id, true
+}
+
+// Send a command on the connection that joined the channel it is for, or
+// any connection if it isn't for a joined channel.
+func (p *Pool) Send(ctx context.Context, command Command) error {
+	_, channel, command, err := route(command)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	s, ok := p.channels[channel]
+	p.mu.Unlock()
+	if !ok {
+		if s, err = p.any(); err != nil {
+			return err
+		}
+	}
+	return s.client.Send(ctx, command)
+}
+
+// Close every connection.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	shards := p.shards
+	p.shards = nil
+	p.mu.Unlock()
+
+	var err error
+	for _, s := range shards {
+		if e := s.client.Close(); e != nil && err == nil {
+			err = e
+		}
+	}
+	return err
+}
+
+// assign a channel to the least loaded shard with room for it, connecting
+// a new shard if there is none. It returns nil if the channel is already
+// assigned.
+func (p *Pool) assign(channel string) (*shard, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.closed {
+			return nil, ErrClosed
+		}
+		if _, ok := p.channels[channel]; ok {
+			return nil, nil
+		}
+		if s := p.least(true); s != nil {
+			s.channels[channel] = true
+			p.channels[channel] = s
+			return s, nil
+		}
+		if err := p.grow(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// unassign a channel that could not be joined.
+func (p *Pool) unassign(s *shard, channel string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.channels[channel] == s {
+		delete(p.channels, channel)
+		delete(s.channels, channel)
+	}
+}
+
+// any returns the least loaded shard, connecting one if there is none.
+func (p *Pool) any() (*shard, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for {
+		if p.closed {
+			return nil, ErrClosed
+		}
+		if s := p.least(false); s != nil {
+			return s, nil
+		}
+		if err := p.grow(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// least returns the shard with the fewest channels, only among those with
+// room for another if room is set. p.mu must be held.
+func (p *Pool) least(room bool) *shard {
+	var best *shard
+	for _, s := range p.shards {
+		if room && len(s.channels) >= p.perConn {
+			continue
+		}
+		if best == nil || len(s.channels) < len(best.channels) {
+			best = s
+		}
+	}
+	return best
+}
+
+// grow connects a new shard. p.mu must be held, and is released while
+// connecting so that the pool stays usable meanwhile.
+func (p *Pool) grow() error {
+	p.mu.Unlock()
+	c, err := NewClient(p.options...)
+	if err == nil {
+		err = c.Connect()
+	}
+	p.mu.Lock()
+	if err != nil {
+		return err
+	}
+	if p.closed {
+		c.Close()
+		return ErrClosed
+	}
+	s := &shard{
+		id:       p.next,
+		client:   c,
+		channels: make(map[string]bool),
+		joins:    newLimiter(20, 10*time.Second),
+	}
+	p.next++
+	p.shards = append(p.shards, s)
+	p.forwarders.Add(1)
+	go p.forward(s)
+	return nil
+}
+
+// forward the events of a shard until it disconnects or the pool is
+// closed.
+func (p *Pool) forward(s *shard) {
+	defer p.forwarders.Done()
+	for ev := range s.client.Events() {
+		if _, ok := ev.(PING); ok {
+			s.client.Submit(Pong())
+		}
+		if !p.emit(ShardEvent{s.id, ev}) {
+			return
+		}
+	}
+	p.lost(s)
+}
+
+// logln logs like the pool's clients do.
+func (p *Pool) logln(a ...interface{}) {
+	fmt.Fprintln(p.log, a...)
+}
+
+// emit an event on Events(), returning false if the pool was closed.
+func (p *Pool) emit(ev ShardEvent) bool {
+	select {
+	case p.events <- ev:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// lost rejoins the channels of a disconnected shard on the others.
+func (p *Pool) lost(s *shard) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	for i, other := range p.shards {
+		if other == s {
+			p.shards = append(p.shards[:i], p.shards[i+1:]...)
+			break
+		}
+	}
+	var channels []string
+	for channel := range s.channels {
+		delete(p.channels, channel)
+		channels = append(channels, channel)
+	}
+	p.mu.Unlock()
+
+	s.client.Close()
+	// Joins are limited to 20 per 10 seconds on each shard.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second+time.Duration(len(channels))*time.Second/2)
+	defer cancel()
+	var failed []string
+	var err error
+	for _, channel := range channels {
+		if e := p.Join(ctx, channel); e != nil {
+			failed, err = append(failed, channel), e
+		}
+	}
+	if err != nil {
+		p.emit(ShardEvent{s.id, &RejoinError{Channels: failed, Err: err}})
+	}
+}
+
+// Env returns the pool as an Env, for use instead of Events. Joins and
+// parts sent through it are made with Join and Part, other commands with
+// Send; errors are logged. Its events end once the pool is closed.
+func (p *Pool) Env() Env {
+	e := &poolEnv{pool: p, commands: make(chan Command), events: make(chan Event)}
+	go func() {
+		defer close(e.events)
+		for ev := range p.events {
+			e.events <- ev.Event
+		}
+	}()
+	go e.dispatch()
+	return e
+}
+
+type poolEnv struct {
+	pool     *Pool
+	commands chan Command
+	events   chan Event
+}
+
+func (e *poolEnv) Command() chan<- Command { return e.commands }
+func (e *poolEnv) Events() <-chan Event    { return e.events }
+func (e *poolEnv) answersPing()            {}
+func (e *poolEnv) logln(a ...interface{})  { e.pool.logln(a...) }
+
+func (e *poolEnv) dispatch() {
+	ctx := context.Background()
+	for command := range e.commands {
+		var buf bytes.Buffer
+		if err := command(&buf); err != nil {
+			e.pool.logln("command failed: ", err)
+			continue
+		}
+		lines, packets := outgoing(buf.Bytes())
+		for i, packet := range packets {
+			var err error
+			switch {
+			case packet.Command == "JOIN" && len(packet.Params) > 0:
+				err = e.pool.Join(ctx, strings.Split(packet.Params[0], ",")...)
+			case packet.Command == "PART" && len(packet.Params) > 0:
+				for _, channel := range strings.Split(packet.Params[0], ",") {
+					if err = e.pool.Part(ctx, channel); err != nil {
+						break
+					}
+				}
+			default:
+				err = e.pool.Send(ctx, Line(string(lines[i])))
+			}
+			if err != nil {
+				e.pool.logln("command failed: ", err)
+			}
+		}
+	}
+}
+
+// join a channel, waiting for the shard's join rate limit.
+func (s *shard) join(ctx context.Context, channel string) error {
+	for !s.joins.allow(1) {
+		select {
+		case <-time.After(100 * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return s.client.Send(ctx, Join(channel))
+}
+
+// route executes a command once to find the verb and channel of its first
+// line for a channel, and returns a command writing the same bytes.
+func route(command Command) (verb, channel string, replay Command, err error) {
+	var buf bytes.Buffer
+	if err := command(&buf); err != nil {
+		return "", "", nil, err
+	}
+	b := buf.Bytes()
+	replay = func(w io.Writer) error {
+		_, err := w.Write(b)
+		return err
+	}
+	_, packets := outgoing(b)
+	for _, p := range packets {
+		if len(p.Params) > 0 && strings.HasPrefix(p.Params[0], "#") && p.Params[0] != "#jtv" {
+			channel = strings.SplitN(p.Params[0][1:], ",", 2)[0]
+			return p.Command, strings.ToLower(channel), replay, nil
+		}
+	}
+	return "", "", replay, nil
+}
@@ -0,0 +1,218 @@
+package tmi
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi/tmitest"
+)
+
+func TestPool(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	p := NewPool(2, Address(s.Addr))
+	defer p.Close()
+	go func() {
+		for range p.Events() {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := p.Join(ctx, "a", "b", "c", "d", "e"); err != nil {
+		t.Fatal(err)
+	}
+	conns := make(map[string]*tmitest.Conn) // by channel
+	var all []*tmitest.Conn
+	for i := 0; i < 3; i++ {
+		conn, err := s.NextConn(ctx)
+		if err != nil {
+			t.Fatalf("connection %d: %v", i, err)
+		}
+		all = append(all, conn)
+	}
+	waitJoined(t, all, 5)
+	for _, conn := range all {
+		if n := len(conn.Channels()); n > 2 {
+			t.Errorf("connection has %d channels, want at most 2", n)
+		}
+		for _, channel := range conn.Channels() {
+			conns[channel] = conn
+		}
+	}
+
+	if err := p.Send(ctx, Say("#C", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	line, err := conns["c"].Next(ctx)
+	for err == nil && strings.HasPrefix(line, "JOIN ") {
+		line, err = conns["c"].Next(ctx)
+	}
+	if err != nil || line != "PRIVMSG #c :hello" {
+		t.Errorf("shard of #c got %q, %v", line, err)
+	}
+
+	// Drop the connection of #a, its channels must be joined again.
+	lost := conns["a"]
+	lostChannels := lost.Channels()
+	lost.Disconnect()
+	conn, err := s.NextConn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	all = []*tmitest.Conn{conn}
+	for _, c := range conns {
+		if c != lost {
+			all = append(all, c)
+		}
+	}
+	waitJoined(t, all, 5)
+	for _, channel := range lostChannels {
+		if _, ok := p.Shard(channel); !ok {
+			t.Errorf("#%s not rejoined", channel)
+		}
+	}
+}
+
+// waitJoined waits until conns have joined n channels between them.
+func waitJoined(t *testing.T, conns []*tmitest.Conn, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		seen := make(map[*tmitest.Conn]bool)
+		var channels []string
+		for _, conn := range conns {
+			if seen[conn] {
+				continue
+			}
+			seen[conn] = true
+			channels = append(channels, conn.Channels()...)
+		}
+		if len(channels) == n {
+			return
+		}
+		if time.Now().After(deadline) {
+			sort.Strings(channels)
+			t.Fatalf("joined %s, want %d channels", strings.Join(channels, ","), n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPoolEnv(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	p := NewPool(1, Address(s.Addr))
+	defer p.Close()
+	env := p.Env()
+	go func() {
+		for range env.Events() {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	env.Command() <- Join("a", "b")
+	var all []*tmitest.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := s.NextConn(ctx)
+		if err != nil {
+			t.Fatalf("connection %d: %v", i, err)
+		}
+		all = append(all, conn)
+	}
+	waitJoined(t, all, 2)
+	if _, ok := p.Shard("b"); !ok {
+		t.Error("#b not joined through the pool")
+	}
+
+	env.Command() <- Say("b", "hello")
+	conn := all[0]
+	if channels := conn.Channels(); len(channels) != 1 || channels[0] != "b" {
+		conn = all[1]
+	}
+	line, err := conn.Next(ctx)
+	for err == nil && strings.HasPrefix(line, "JOIN ") {
+		line, err = conn.Next(ctx)
+	}
+	if err != nil || line != "PRIVMSG #b :hello" {
+		t.Errorf("shard of #b got %q, %v", line, err)
+	}
+}
+
+func TestPoolJoinFailed(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	p := NewPool(10, Address(s.Addr))
+	closed := make(chan struct{})
+	go func() {
+		for range p.Events() {
+		}
+		close(closed)
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.Join(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.shards[0].joins = newLimiter(0, time.Hour)
+	p.mu.Unlock()
+	short, cancelShort := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelShort()
+	if err := p.Join(short, "b"); err != context.DeadlineExceeded {
+		t.Errorf("Join() = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if _, ok := p.Shard("b"); ok {
+		t.Error("#b assigned to a shard after failing to join")
+	}
+
+	p.Close()
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Error("Events() not closed after Close")
+	}
+}
+
+func TestPoolPresence(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	pr := NewPresence()
+	p := NewPool(1, Address(s.Addr), Track(pr))
+	defer p.Close()
+	go func() {
+		for range p.Events() {
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	present := func(channel string) {
+		t.Helper()
+		for len(pr.Chatters(channel)) != 1 {
+			if ctx.Err() != nil {
+				t.Fatalf("client not present in #%s", channel)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if err := p.Join(ctx, "a"); err != nil {
+		t.Fatal(err)
+	}
+	present("a")
+	// The new shard's welcome must not wipe the first shard's channel.
+	if err := p.Join(ctx, "b"); err != nil {
+		t.Fatal(err)
+	}
+	present("b")
+	if chatters := pr.Chatters("a"); len(chatters) != 1 {
+		t.Errorf("Chatters(a) = %v after joining #b on another shard", chatters)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io"
 )
 
+// Prefixer is a writer prefixing every line written to it.
 type Prefixer struct {
 	prefixFunc      func() string
 	writer          io.Writer
@@ -12,7 +13,7 @@ type Prefixer struct {
 	buf             bytes.Buffer // reuse buffer to save allocations
 }
 
-// New creates a new Prefixer that forwards all calls to Write() to writer.Write() with all lines prefixed with the
+// NewPrefixer creates a new Prefixer that forwards all calls to Write() to writer.Write() with all lines prefixed with the
 // return value of prefixFunc. Having a function instead of a static prefix allows to print timestamps or other changing
 // information.
 func NewPrefixer(writer io.Writer, prefixFunc func() string) *Prefixer {
@@ -0,0 +1,162 @@
+package tmi
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Chatter is a user present in a channel.
+type Chatter struct {
+	Login    string
+	Joined   time.Time // When the user was first seen in the channel.
+	LastSeen time.Time // When the user last joined or sent a message.
+}
+
+// Presence tracks the chatters in each channel from JOIN, PART and NAMES
+// events, which require CapMembership, and from PRIVMSG. Register it with
+// Track, or feed it events with Handle. A channel is forgotten when the
+// client parts it, and starts over when the client joins it again. A
+// Presence may be shared by several clients, such as the shards of a Pool.
+//
+// Twitch batches JOIN and PART and only lists chatters in NAMES for
+// channels with fewer than 1000 of them, so presence lags by a few
+// seconds and is incomplete for large channels.
+type Presence struct {
+	// OnJoin and OnPart, if set, are called when a chatter arrives or
+	// leaves. They are called from Handle and must not block.
+	OnJoin func(channel string, chatter Chatter)
+	OnPart func(channel string, chatter Chatter)
+
+	mu       sync.Mutex
+	channels map[string]map[string]*Chatter
+	logins   map[string]bool // of the clients, from the server's welcome
+	now      func() time.Time
+}
+
+// NewPresence creates an empty presence tracker.
+func NewPresence() *Presence {
+	return &Presence{
+		channels: make(map[string]map[string]*Chatter),
+		logins:   make(map[string]bool),
+		now:      time.Now,
+	}
+}
+
+// Handle updates presence with an event.
+func (p *Presence) Handle(event Event) {
+	switch ev := event.(type) {
+	case JOIN:
+		p.join(ev.Channel(), ev.Login())
+	case NAMES:
+		if ev.Channel() == "" {
+			return
+		}
+		for _, login := range ev.Logins() {
+			p.seen(ev.Channel(), login)
+		}
+	case PRIVMSG:
+		p.seen(ev.Channel(), ev.Author())
+	case PART:
+		p.part(ev.Channel(), ev.Login())
+	case UNKNOWN:
+		if ev.Command == "001" && len(ev.Params) > 0 {
+			p.welcome(ev.Params[0])
+		}
+	}
+}
+
+// welcome learns the login of a client from the server's welcome.
+func (p *Presence) welcome(login string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.logins[strings.ToLower(login)] = true
+}
+
+func (p *Presence) join(channel, login string) {
+	p.mu.Lock()
+	if p.logins[strings.ToLower(login)] {
+		// The client joined, again after a reconnect, and is about to be
+		// told who is there.
+		delete(p.channels, channel)
+	}
+	p.mu.Unlock()
+	p.seen(channel, login)
+}
+
+func (p *Presence) seen(channel, login string) {
+	login = strings.ToLower(login)
+	p.mu.Lock()
+	now := p.now()
+	chatters, ok := p.channels[channel]
+	if !ok {
+		chatters = make(map[string]*Chatter)
+		p.channels[channel] = chatters
+	}
+	chatter, ok := chatters[login]
+	if ok {
+		chatter.LastSeen = now
+		p.mu.Unlock()
+		return
+	}
+	chatter = &Chatter{Login: login, Joined: now, LastSeen: now}
+	chatters[login] = chatter
+	arrived := *chatter
+	p.mu.Unlock()
+
+	if p.OnJoin != nil {
+		p.OnJoin(channel, arrived)
+	}
+}
+
+func (p *Presence) part(channel, login string) {
+	login = strings.ToLower(login)
+	p.mu.Lock()
+	if p.logins[login] {
+		// The client left, and no longer knows who is there.
+		delete(p.channels, channel)
+		p.mu.Unlock()
+		return
+	}
+	chatter, ok := p.channels[channel][login]
+	if !ok {
+		p.mu.Unlock()
+		return
+	}
+	delete(p.channels[channel], login)
+	if len(p.channels[channel]) == 0 {
+		delete(p.channels, channel)
+	}
+	left := *chatter
+	p.mu.Unlock()
+
+	if p.OnPart != nil {
+		p.OnPart(channel, left)
+	}
+}
+
+// Chatters returns the chatters present in a channel, sorted by login.
+func (p *Presence) Chatters(channel string) []Chatter {
+	channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	chatters := make([]Chatter, 0, len(p.channels[channel]))
+	for _, chatter := range p.channels[channel] {
+		chatters = append(chatters, *chatter)
+	}
+	sort.Slice(chatters, func(i, j int) bool { return chatters[i].Login < chatters[j].Login })
+	return chatters
+}
+
+// Chatter returns a chatter present in a channel.
+func (p *Presence) Chatter(channel, login string) (Chatter, bool) {
+	channel = strings.ToLower(strings.TrimPrefix(channel, "#"))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	chatter, ok := p.channels[channel][strings.ToLower(login)]
+	if !ok {
+		return Chatter{}, false
+	}
+	return *chatter, true
+}
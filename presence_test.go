@@ -0,0 +1,75 @@
+package tmi
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPresence(t *testing.T) {
+	now := time.Unix(1000, 0)
+	p := NewPresence()
+	p.now = func() time.Time { return now }
+	var joined, parted []string
+	p.OnJoin = func(channel string, c Chatter) { joined = append(joined, channel+"/"+c.Login) }
+	p.OnPart = func(channel string, c Chatter) { parted = append(parted, channel+"/"+c.Login) }
+
+	handle := func(line string) {
+		p.Handle(toevent(mustParse(t, line)))
+	}
+	handle(":bot.tmi.twitch.tv 353 bot = #bar :foo bar bot")
+	handle(":bot.tmi.twitch.tv 366 bot #bar :End of /NAMES list")
+	now = now.Add(time.Minute)
+	handle(":baz!baz@baz.tmi.twitch.tv JOIN #bar")
+	handle(":foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :hello")
+	handle(":bar!bar@bar.tmi.twitch.tv PART #bar")
+	handle(":qux!qux@qux.tmi.twitch.tv PART #bar")
+
+	if want := []string{"bar/foo", "bar/bar", "bar/bot", "bar/baz"}; !reflect.DeepEqual(joined, want) {
+		t.Errorf("OnJoin called with %v, want %v", joined, want)
+	}
+	if want := []string{"bar/bar"}; !reflect.DeepEqual(parted, want) {
+		t.Errorf("OnPart called with %v, want %v", parted, want)
+	}
+
+	var logins []string
+	for _, c := range p.Chatters("#Bar") {
+		logins = append(logins, c.Login)
+	}
+	if want := []string{"baz", "bot", "foo"}; !reflect.DeepEqual(logins, want) {
+		t.Errorf("Chatters() = %v, want %v", logins, want)
+	}
+
+	foo, ok := p.Chatter("bar", "Foo")
+	if !ok || !foo.Joined.Equal(time.Unix(1000, 0)) || !foo.LastSeen.Equal(now) {
+		t.Errorf("Chatter() = %+v, %v", foo, ok)
+	}
+}
+
+func TestPresenceLeave(t *testing.T) {
+	p := NewPresence()
+	handle := func(line string) {
+		p.Handle(toevent(mustParse(t, line)))
+	}
+	handle(":tmi.twitch.tv 001 bot :Welcome, GLHF!")
+	handle(":bot.tmi.twitch.tv 353 bot = #bar :foo bot")
+	handle(":bot.tmi.twitch.tv 353 bot = #baz :foo bot")
+	handle(":bot.tmi.twitch.tv 353 bot")
+	handle(":bot!bot@bot.tmi.twitch.tv PART #bar")
+	if chatters := p.Chatters("bar"); len(chatters) != 0 {
+		t.Errorf("Chatters() = %v after leaving", chatters)
+	}
+	if chatters := p.Chatters("baz"); len(chatters) != 2 {
+		t.Errorf("Chatters() = %v, want 2 chatters", chatters)
+	}
+
+	// Another client sharing the Presence connects.
+	handle(":tmi.twitch.tv 001 bot :Welcome, GLHF!")
+	if chatters := p.Chatters("baz"); len(chatters) != 2 {
+		t.Errorf("Chatters() = %v after another client connected", chatters)
+	}
+	handle(":bot!bot@bot.tmi.twitch.tv JOIN #baz")
+	if chatters := p.Chatters("baz"); len(chatters) != 1 {
+		t.Errorf("Chatters() = %v after joining again, want only the client", chatters)
+	}
+}
@@ -0,0 +1,138 @@
+package tmi
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Directions of recorded lines.
+const (
+	Inbound  = "<"
+	Outbound = ">"
+)
+
+// Recorder writes raw traffic, one IRC line per line, as a timestamp, a
+// direction and the line:
+//
+//	2022-01-20T21:54:55.392Z < :tmi.twitch.tv PING
+//	2022-01-20T21:54:55.401Z > PONG :tmi.twitch.tv
+//
+// Passwords are not recorded.
+type Recorder struct {
+	mu      sync.Mutex
+	in, out *Prefixer
+	now     func() time.Time
+}
+
+// NewRecorder creates a Recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{now: time.Now}
+	r.in = NewPrefixer(w, r.prefix(Inbound))
+	r.out = NewPrefixer(w, r.prefix(Outbound))
+	return r
+}
+
+func (r *Recorder) prefix(dir string) func() string {
+	return func() string {
+		return r.now().UTC().Format(time.RFC3339Nano) + " " + dir + " "
+	}
+}
+
+// Record makes the client write its traffic to r.
+func Record(r *Recorder) Option {
+	return func(c *Client) {
+		c.recorder = r
+	}
+}
+
+// record a line of the client's traffic if it has a Recorder.
+func (c *Client) record(dir string, line []byte) {
+	if c.recorder == nil {
+		return
+	}
+	record := c.recorder.Inbound
+	if dir == Outbound {
+		record = c.recorder.Outbound
+	}
+	if err := record(line); err != nil {
+		c.logln("failed to record traffic: ", err)
+	}
+}
+
+// Inbound records a line received from the server.
+func (r *Recorder) Inbound(line []byte) error {
+	return r.write(r.in, line)
+}
+
+// Outbound records a line sent to the server.
+func (r *Recorder) Outbound(line []byte) error {
+	if len(line) > 5 && strings.EqualFold(string(line[:5]), "PASS ") {
+		line = []byte("PASS ***")
+	}
+	return r.write(r.out, line)
+}
+
+func (r *Recorder) write(pf *Prefixer, line []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, err := pf.Write(append(line[:len(line):len(line)], '\n'))
+	return err
+}
+
+// Replay reads a recording and sends the events of its inbound lines on
+// events, pausing between them as long as the recording did divided by
+// speed. A speed <= 0 replays as fast as events are received. Replay
+// returns once the recording ends or ctx expires.
+func Replay(ctx context.Context, recording io.Reader, speed float64, events chan<- Event) error {
+	scanner := bufio.NewScanner(recording)
+	scanner.Buffer(nil, 1<<20)
+	var last time.Time
+	for n := 1; scanner.Scan(); n++ {
+		ts, dir, line, err := parseRecord(scanner.Text())
+		if err != nil {
+			return fmt.Errorf("tmi: recording line %d: %w", n, err)
+		}
+		if dir != Inbound {
+			continue
+		}
+		p, err := parsePacket([]byte(line))
+		if err != nil {
+			return fmt.Errorf("tmi: recording line %d: %w", n, err)
+		}
+		if speed > 0 && !last.IsZero() && ts.After(last) {
+			timer := time.NewTimer(time.Duration(float64(ts.Sub(last)) / speed))
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return ctx.Err()
+			}
+		}
+		last = ts
+		select {
+		case events <- toevent(p):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return scanner.Err()
+}
+
+// parseRecord splits a recorded line into its timestamp, direction and
+// IRC line.
+func parseRecord(record string) (time.Time, string, string, error) {
+	fields := strings.SplitN(record, " ", 3)
+	if len(fields) != 3 || (fields[1] != Inbound && fields[1] != Outbound) {
+		return time.Time{}, "", "", fmt.Errorf("malformed record %q", record)
+	}
+	ts, err := time.Parse(time.RFC3339Nano, fields[0])
+	if err != nil {
+		return time.Time{}, "", "", err
+	}
+	return ts, fields[1], fields[2], nil
+}
@@ -0,0 +1,76 @@
+package tmi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecord(t *testing.T) {
+	var buf bytes.Buffer
+	r := NewRecorder(&buf)
+	r.now = func() time.Time { return time.Unix(1642715695, 392e6) }
+	c, _ := NewClient(Auth("bot", "oauth:secret"), Record(r))
+	server, lines := pipeClient(t, c)
+	defer server.Close()
+	go io.Copy(ioutil.Discard, lines)
+
+	fmt.Fprint(server, "PING :tmi.twitch.tv\r\n")
+	ev := <-c.Events()
+	c.Default(ev)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := c.Send(ctx, Say("nymn", "hi")); err != nil {
+		t.Fatal(err)
+	}
+
+	got := buf.String()
+	for _, want := range []string{
+		"2022-01-20T21:54:55.392Z > PASS ***\n",
+		"2022-01-20T21:54:55.392Z > NICK bot\n",
+		"2022-01-20T21:54:55.392Z < PING :tmi.twitch.tv\n",
+		"2022-01-20T21:54:55.392Z > PRIVMSG #nymn :hi\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("recording lacks %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret") {
+		t.Errorf("recording contains the password:\n%s", got)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	recording := strings.Join([]string{
+		"2022-01-20T21:54:55Z > JOIN #nymn",
+		"2022-01-20T21:54:55Z < :a!a@a.tmi.twitch.tv JOIN #nymn",
+		"2022-01-20T21:54:56Z < :a!a@a.tmi.twitch.tv PRIVMSG #nymn :hi",
+	}, "\n")
+
+	events := make(chan Event, 10)
+	start := time.Now()
+	if err := Replay(context.Background(), strings.NewReader(recording), 100, events); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 10*time.Millisecond {
+		t.Errorf("replayed in %v, want at least 10ms at 100x", elapsed)
+	}
+	close(events)
+	var got []string
+	for ev := range events {
+		got = append(got, fmt.Sprintf("%T", ev))
+	}
+	if want := []string{"tmi.JOIN", "tmi.PRIVMSG"}; strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("replayed %v, want %v", got, want)
+	}
+
+	err := Replay(context.Background(), strings.NewReader("garbage"), 0, events)
+	if err == nil {
+		t.Error("Replay() of a malformed recording = nil")
+	}
+}
@@ -0,0 +1,465 @@
+package tmi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrRateLimited is reported when a command is rejected by the rate limiter.
+	ErrRateLimited = errors.New("tmi: rate limited")
+	// ErrClosed is reported when a command is submitted to a closed client.
+	ErrClosed = errors.New("tmi: client closed")
+)
+
+// Status is the delivery status of a submitted command.
+type Status int
+
+const (
+	Pending     Status = iota // Not yet handled by the write loop.
+	Written                   // Written to the connection buffer.
+	Flushed                   // Flushed to the socket.
+	RateLimited               // Rejected by the rate limiter, nothing was written.
+	Failed                    // The command or the connection reported an error.
+	Restricted                // Rejected because of the channel's settings, nothing was written.
+)
+
+func (s Status) String() string {
+	switch s {
+	case Pending:
+		return "pending"
+	case Written:
+		return "written"
+	case Flushed:
+		return "flushed"
+	case RateLimited:
+		return "rate limited"
+	case Failed:
+		return "failed"
+	case Restricted:
+		return "restricted"
+	default:
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+}
+
+// Result is the future outcome of a submitted command.
+type Result struct {
+	mu     sync.Mutex
+	status Status
+	err    error
+	done   chan struct{}
+
+	// Server acknowledgements of the PRIVMSGs in the command.
+	acks   int
+	ackErr error
+	acked  chan struct{}
+}
+
+func newResult() *Result {
+	return &Result{done: make(chan struct{}), acked: make(chan struct{})}
+}
+
+// Status returns the current status of the command.
+func (r *Result) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.status
+}
+
+// Err returns the error the command failed with, if any.
+func (r *Result) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Done is closed once the command has been flushed or rejected.
+func (r *Result) Done() <-chan struct{} { return r.done }
+
+// Wait blocks until the command is done or ctx expires.
+func (r *Result) Wait(ctx context.Context) error {
+	select {
+	case <-r.done:
+		return r.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Ack blocks until the server has acknowledged every message in the
+// command, or ctx expires. A message the server rejected is reported as a
+// *NoticeError, one it didn't answer within 30 seconds as ErrNoAck, and one
+// still unanswered when the connection ended as ErrClosed. Commands without
+// messages are acknowledged once they are done.
+func (r *Result) Ack(ctx context.Context) error {
+	select {
+	case <-r.acked:
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.ackErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// expect n acknowledgements from the server.
+func (r *Result) expect(n int) {
+	r.mu.Lock()
+	r.acks = n
+	r.mu.Unlock()
+}
+
+func (r *Result) ack(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.acks == 0 {
+		return
+	}
+	if r.ackErr == nil {
+		r.ackErr = err
+	}
+	r.acks--
+	if r.acks == 0 {
+		close(r.acked)
+	}
+}
+
+func (r *Result) set(status Status) {
+	r.mu.Lock()
+	r.status = status
+	r.mu.Unlock()
+}
+
+func (r *Result) resolve(status Status, err error) {
+	r.mu.Lock()
+	r.status, r.err = status, err
+	if err != nil || r.acks == 0 {
+		// Nothing more will be acknowledged.
+		select {
+		case <-r.acked:
+		default:
+			if r.ackErr == nil {
+				r.ackErr = err
+			}
+			r.acks = 0
+			close(r.acked)
+		}
+	}
+	r.mu.Unlock()
+	close(r.done)
+}
+
+type request struct {
+	command Command
+	result  *Result // nil for commands sent through Command()
+}
+
+func (req request) set(status Status) {
+	if req.result != nil {
+		req.result.set(status)
+	}
+}
+
+func (req request) resolve(status Status, err error) {
+	if req.result != nil {
+		req.result.resolve(status, err)
+	}
+}
+
+// Submit a command to the server and return its future result.
+func (c *Client) Submit(command Command) *Result {
+	req := request{command, newResult()}
+	select {
+	case c.requests <- req:
+	case <-c.closed:
+		req.resolve(Failed, ErrClosed)
+	}
+	return req.result
+}
+
+// enqueue a command like sending it on Command(), but fail with ErrClosed
+// once the client is closed.
+func (c *Client) enqueue(command Command) error {
+	select {
+	case c.requests <- request{command: command}:
+		return nil
+	case <-c.closed:
+		return ErrClosed
+	}
+}
+
+// Send a command to the server and block until it is flushed to the socket,
+// rejected, or ctx expires. Messages to a channel in slow mode are delayed
+// until slow mode allows them, and commands with several messages to it are
+// rejected.
+func (c *Client) Send(ctx context.Context, command Command) error {
+	_, err := c.send(ctx, command)
+	return err
+}
+
+// Deliver a command to the server like Send, and block until the server
+// has acknowledged its messages, rejected one of them, or ctx expires.
+func (c *Client) Deliver(ctx context.Context, command Command) error {
+	result, err := c.send(ctx, command)
+	if err != nil {
+		return err
+	}
+	return result.Ack(ctx)
+}
+
+func (c *Client) send(ctx context.Context, command Command) (*Result, error) {
+	for {
+		result, err := c.submit(ctx, command)
+		if err != nil {
+			return nil, err
+		}
+		err = result.Wait(ctx)
+		var restriction *RestrictionError
+		if !errors.As(err, &restriction) || restriction.Wait <= 0 {
+			return result, err
+		}
+		timer := time.NewTimer(restriction.Wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (c *Client) submit(ctx context.Context, command Command) (*Result, error) {
+	req := request{command, newResult()}
+	select {
+	case c.requests <- req:
+		return req.result, nil
+	case <-c.closed:
+		return nil, ErrClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// writeLoop writes commands to conn. Joins and parts are also written to
+// mirror, if not nil, and PINGs of either connection are answered on it.
+func (c *Client) writeLoop(conn, mirror io.Writer) {
+	w := bufio.NewWriter(conn)
+	var m *bufio.Writer
+	if mirror != nil {
+		m = bufio.NewWriter(mirror)
+	}
+	// w := io.MultiWriter(conn, NewPrefixer(os.Stdout, func() string { return "-> " }))
+	for {
+		var req request
+		select {
+		case command, ok := <-c.commands:
+			if !ok {
+				return
+			}
+			req = request{command: command}
+		case req = <-c.requests:
+		case role := <-c.pongs:
+			conn := w
+			if role == readOnly {
+				conn = m
+			}
+			if err := c.pong(conn); err != nil {
+				c.logln("failed to answer PING: ", err)
+			}
+			continue
+		}
+		if err := c.write(w, m, req); err != nil && req.result == nil {
+			c.logln("command failed: ", err)
+		}
+	}
+}
+
+// write executes a single request against w, and m if not nil, returning
+// the error the request was resolved with.
+func (c *Client) write(w, m *bufio.Writer, req request) error {
+	// Commands are executed into a buffer first so that nothing reaches
+	// the wire unless the whole command is valid and allowed.
+	var buf bytes.Buffer
+	if err := req.command(&buf); err != nil {
+		req.resolve(Failed, err)
+		return err
+	}
+	lines, packets := outgoing(buf.Bytes())
+	var messages []int      // indices of the PRIVMSGs to a channel
+	var chatCommands int    // PRIVMSGs such as /ban, which count as messages
+	var recipients []string // of whispers
+	var tracked []int       // messages and chat commands, in order
+	for i, p := range packets {
+		if p.Command != "PRIVMSG" || len(p.Params) != 2 || !strings.HasPrefix(p.Params[0], "#") {
+			continue
+		}
+		if recipient, ok := whisperRecipient(p); ok {
+			recipients = append(recipients, recipient)
+		} else if isChatCommand(p.Params[1]) {
+			chatCommands++
+			tracked = append(tracked, i)
+		} else {
+			messages = append(messages, i)
+			tracked = append(tracked, i)
+		}
+	}
+	// Limits are all checked before any is recorded, so that a rejected
+	// command uses up none of them.
+	if !c.whispers.check(recipients...) {
+		req.resolve(RateLimited, ErrRateLimited)
+		return ErrRateLimited
+	}
+	perChannel := make(map[string]int)
+	for _, i := range messages {
+		perChannel[packets[i].Params[0][1:]]++
+	}
+	for _, i := range messages {
+		channel := packets[i].Params[0][1:]
+		if err := c.restriction(channel, perChannel[channel]); err != nil {
+			req.resolve(Restricted, err)
+			return err
+		}
+	}
+	n := len(messages) + chatCommands
+	if n > 0 && !c.limiter.check(n) {
+		req.resolve(RateLimited, ErrRateLimited)
+		return ErrRateLimited
+	}
+	c.whispers.add(recipients...)
+	c.limiter.add(n)
+	last := make(map[string]string) // message by channel
+	for _, i := range messages {
+		if c.unique(&packets[i], last) {
+			lines[i] = []byte(packets[i].String())
+		}
+	}
+	// Track before writing, the server may answer before Flush returns.
+	if req.result != nil {
+		req.result.expect(len(messages))
+	}
+	for _, i := range tracked {
+		if isChatCommand(packets[i].Params[1]) {
+			c.tracker.sentCommand(packets[i].Params[0][1:])
+		} else {
+			c.tracker.sent(packets[i].Params[0][1:], req.result)
+		}
+	}
+	if m != nil {
+		if err := mirror(m, lines, packets); err != nil {
+			req.resolve(Failed, err)
+			return err
+		}
+	}
+	for _, line := range lines {
+		if _, err := w.Write(line); err != nil {
+			req.resolve(Failed, err)
+			return err
+		}
+		if _, err := w.WriteString(Delim); err != nil {
+			req.resolve(Failed, err)
+			return err
+		}
+		c.logln("->", string(line))
+		c.record(Outbound, line)
+	}
+	req.set(Written)
+	if err := w.Flush(); err != nil {
+		req.resolve(Failed, err)
+		return err
+	}
+	c.sent(last)
+	req.resolve(Flushed, nil)
+	return nil
+}
+
+// pong answers a PING on w, without the checks of write.
+func (c *Client) pong(w *bufio.Writer) error {
+	if _, err := w.WriteString(pongLine + Delim); err != nil {
+		return err
+	}
+	c.logln("->", pongLine)
+	c.record(Outbound, []byte(pongLine))
+	return w.Flush()
+}
+
+// mirror writes the lines that concern the connection state to w.
+func mirror(w *bufio.Writer, lines [][]byte, packets []Packet) error {
+	var n int
+	for i, p := range packets {
+		switch p.Command {
+		case "JOIN", "PART":
+		default:
+			continue
+		}
+		if _, err := w.Write(lines[i]); err != nil {
+			return err
+		}
+		if _, err := w.WriteString(Delim); err != nil {
+			return err
+		}
+		n++
+	}
+	if n == 0 {
+		return nil
+	}
+	return w.Flush()
+}
+
+// whisperRecipient returns the recipient if p is a whisper command.
+func whisperRecipient(p Packet) (string, bool) {
+	if p.Command != "PRIVMSG" || len(p.Params) != 2 {
+		return "", false
+	}
+	fields := strings.Fields(p.Params[1])
+	if len(fields) < 2 || (fields[0] != "/w" && fields[0] != ".w") {
+		return "", false
+	}
+	return strings.ToLower(fields[1]), true
+}
+
+// chatCommandNames are the names of Twitch's chat commands, other than /me
+// which sends a message.
+var chatCommandNames = func() map[string]bool {
+	names := map[string]bool{
+		"untimeout": true, "color": true, "commercial": true, "raid": true,
+		"unraid": true, "host": true, "unhost": true, "mods": true,
+		"vips": true, "r9kbeta": true, "r9kbetaoff": true, "block": true,
+		"unblock": true, "disconnect": true, "help": true,
+	}
+	for _, command := range commands {
+		names[command[1:]] = true
+	}
+	return names
+}()
+
+// isChatCommand reports whether a PRIVMSG's text is a command such as
+// /ban rather than a message, such as "/me waves" or "...".
+func isChatCommand(text string) bool {
+	if text == "" || (text[0] != '/' && text[0] != '.') {
+		return false
+	}
+	name := strings.ToLower(strings.SplitN(text[1:], " ", 2)[0])
+	return chatCommandNames[name]
+}
+
+// outgoing splits the output of a command into lines and parses them.
+// Lines that fail to parse have a zero Packet.
+func outgoing(b []byte) (lines [][]byte, packets []Packet) {
+	for _, line := range bytes.Split(b, []byte(Delim)) {
+		if len(line) == 0 {
+			continue
+		}
+		p, _ := parsePacket(line)
+		lines = append(lines, line)
+		packets = append(packets, p)
+	}
+	return lines, packets
+}
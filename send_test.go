@@ -0,0 +1,266 @@
+package tmi
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+)
+
+// pipeClient starts c on one end of a pipe and returns the server end,
+// with the handshake already consumed.
+func pipeClient(t *testing.T, c *Client) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	client, server := net.Pipe()
+	r := bufio.NewReader(server)
+	go c.start(client, nil)
+	for i := 0; i < 3; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return server, r
+}
+
+func TestSend(t *testing.T) {
+	c, _ := NewClient(RateLimit(2, time.Minute))
+	_, r := pipeClient(t, c)
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := c.Send(ctx, Say("nymn", "one")); err != nil {
+		t.Fatalf("Send() = %v", err)
+	}
+	if got := <-lines; got != "PRIVMSG #nymn :one\r\n" {
+		t.Errorf("server got %q", got)
+	}
+
+	res := c.Submit(Say("nymn", "two"))
+	if err := res.Wait(ctx); err != nil || res.Status() != Flushed {
+		t.Errorf("Submit() = %v, %v; want flushed", res.Status(), err)
+	}
+	<-lines
+
+	res = c.Submit(Say("nymn", "three"))
+	if err := res.Wait(ctx); !errors.Is(err, ErrRateLimited) || res.Status() != RateLimited {
+		t.Errorf("Submit() = %v, %v; want rate limited", res.Status(), err)
+	}
+
+	if err := c.Send(ctx, Say("nymn", "a\r\nb")); !errors.Is(err, ErrInvalidLine) {
+		t.Errorf("Send() = %v, want %v", err, ErrInvalidLine)
+	}
+
+	// JOIN is not a message and is not rate limited.
+	if err := c.Send(ctx, Join("forsen")); err != nil {
+		t.Errorf("Send() = %v", err)
+	}
+	select {
+	case line := <-lines:
+		if line != "JOIN #forsen\r\n" {
+			t.Errorf("server got %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Error("no line written")
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter(3, 30*time.Second)
+	l.now = func() time.Time { return now }
+
+	if !l.allow(2) || !l.allow(1) {
+		t.Fatal("want first three allowed")
+	}
+	if l.allow(1) {
+		t.Fatal("want fourth rejected")
+	}
+	now = now.Add(30 * time.Second)
+	if !l.allow(3) {
+		t.Fatal("want window to slide")
+	}
+	if l.allow(1) {
+		t.Fatal("want rejected after refill")
+	}
+	var nilLimiter *limiter
+	if !nilLimiter.allow(100) {
+		t.Fatal("want nil limiter to allow")
+	}
+}
+
+func TestBypassDuplicates(t *testing.T) {
+	c, _ := NewClient(BypassDuplicates)
+	_, r := pipeClient(t, c)
+	lines := make(chan string, 10)
+	go func() {
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			lines <- line
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	want := []string{
+		"PRIVMSG #nymn :hi\r\n",
+		"PRIVMSG #nymn :hi \U000E0000\r\n",
+		"PRIVMSG #nymn :hi\r\n",
+		"PRIVMSG #forsen :hi\r\n",
+	}
+	for _, channel := range []string{"nymn", "nymn", "nymn", "forsen"} {
+		if err := c.Send(ctx, Say(channel, "hi")); err != nil {
+			t.Fatalf("Send() = %v", err)
+		}
+	}
+	for _, want := range want {
+		if got := <-lines; got != want {
+			t.Errorf("server got %q, want %q", got, want)
+		}
+	}
+	if _, ok := c.Channel("nymn"); ok {
+		t.Error("Channel() of a channel never joined = true")
+	}
+}
+
+// A message that could not be written is no duplicate of the next one.
+func TestBypassDuplicatesFailedWrite(t *testing.T) {
+	c, _ := NewClient(BypassDuplicates, Log(ioutil.Discard))
+	failing := bufio.NewWriterSize(failWriter{}, 16)
+	if err := c.write(failing, nil, request{command: Say("nymn", "hi")}); err == nil {
+		t.Fatal("write to a failing connection succeeded")
+	}
+	var buf bytes.Buffer
+	if err := c.write(bufio.NewWriter(&buf), nil, request{command: Say("nymn", "hi")}); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != "PRIVMSG #nymn :hi\r\n" {
+		t.Errorf("wrote %q after a failed write", got)
+	}
+}
+
+type failWriter struct{}
+
+func (failWriter) Write([]byte) (int, error) { return 0, io.ErrClosedPipe }
+
+func TestWhisperLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	w := newWhisperLimiter(3, 5, 2)
+	w.now = func() time.Time { return now }
+	w.perSecond.now = w.now
+	w.perMinute.now = w.now
+	whisper := func(recipients ...string) bool {
+		if !w.check(recipients...) {
+			return false
+		}
+		w.add(recipients...)
+		return true
+	}
+
+	// Failed checks record nothing.
+	if w.check("foo", "foo", "foo", "foo") {
+		t.Fatal("want 4 whispers in a second rejected")
+	}
+	if w.check("foo", "bar", "baz") {
+		t.Fatal("want 3 recipients in a day rejected")
+	}
+	if !whisper("foo", "foo") || !whisper("foo") {
+		t.Fatal("want 3 whispers in a second allowed")
+	}
+	if whisper("foo") {
+		t.Fatal("want 4th whisper in a second rejected")
+	}
+	now = now.Add(time.Second)
+	if !whisper("bar", "bar") {
+		t.Fatal("want whispers allowed after a second")
+	}
+	if whisper("bar") {
+		t.Fatal("want 6th whisper in a minute rejected")
+	}
+	now = now.Add(time.Minute)
+	if whisper("baz") {
+		t.Fatal("want 3rd recipient in a day rejected")
+	}
+	if !whisper("foo") {
+		t.Fatal("want known recipient allowed")
+	}
+	now = now.Add(24 * time.Hour)
+	if !whisper("baz") {
+		t.Fatal("want new recipient allowed the next day")
+	}
+}
+
+// A rejected command uses up none of the limits.
+func TestLimitsCheckedBeforeRecorded(t *testing.T) {
+	c, _ := NewClient(RateLimit(1, time.Minute), WhisperRateLimit(2, 10, 10), Log(ioutil.Discard))
+	var buf bytes.Buffer
+	w := bufio.NewWriter(&buf)
+	whispers := func(w io.Writer) error {
+		for _, user := range []string{"a", "b", "c"} {
+			if err := Whisper(user, "hi")(w); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err := c.write(w, nil, request{command: whispers}); err != ErrRateLimited {
+		t.Fatalf("3 whispers at 2 per second = %v, want %v", err, ErrRateLimited)
+	}
+	both := func(w io.Writer) error {
+		if err := Whisper("a", "hi")(w); err != nil {
+			return err
+		}
+		return Say("nymn", "hi")(w)
+	}
+	c.limiter.add(1)
+	if err := c.write(w, nil, request{command: both}); err != ErrRateLimited {
+		t.Fatalf("message over the limit = %v, want %v", err, ErrRateLimited)
+	}
+	if err := c.write(w, nil, request{command: Whisper("a", "hi")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.write(w, nil, request{command: Whisper("b", "hi")}); err != nil {
+		t.Errorf("second whisper rejected, rejected commands used up the limit: %v", err)
+	}
+}
+
+func TestIsChatCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		want bool
+	}{
+		{"/ban nymn", true},
+		{".timeout nymn 10", true},
+		{"/CLEAR", true},
+		{"/me waves", false},
+		{"/me", false},
+		{"...", false},
+		{"/shrug", false},
+		{"hello", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isChatCommand(tt.text); got != tt.want {
+			t.Errorf("isChatCommand(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
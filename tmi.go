@@ -2,15 +2,27 @@ package tmi
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"net"
+	"os"
+	"reflect"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 var (
+	// ErrInvalidLine is returned by commands whose line contains CR, LF or NUL.
+	ErrInvalidLine = errors.New("tmi: invalid line")
+	// ErrInvalidChannel is returned by commands given a malformed channel name.
+	ErrInvalidChannel = errors.New("tmi: invalid channel name")
+	// ErrInvalidUser is returned by commands given a malformed user login.
+	ErrInvalidUser = errors.New("tmi: invalid user login")
+
 	errNoCommandsCap   = errors.New("tmi: no commands capability")
 	errNoMembershipCap = errors.New("tmi: no membership capability")
 	errNoTagsCap       = errors.New("tmi: no tags capability")
@@ -24,16 +36,42 @@ type (
 	CLEARCHAT  Packet // Purge a user’s message(s), typically after a user is banned from chat or timed out.
 	CLEARMSG   Packet // Single message removal on a channel. This is triggered via /delete <target-msg-id> on IRC.
 	HOSTTARGET Packet // Channel starts or stops host mode.
+	JOIN       Packet // A user joined a channel. Requires CapMembership.
+	NAMES      Packet // 353 RPL_NAMREPLY, users already in a channel when joining. Requires CapMembership.
 	NOTICE     Packet // General notices from the server.
+	PART       Packet // A user left a channel. Requires CapMembership.
 	PING       Packet
 	PRIVMSG    Packet
+	RAID       Packet // A USERNOTICE announcing that a channel raided this one, see (*USERNOTICE).Raid.
 	RECONNECT  Packet // Rejoin channels after a restart.
 	ROOMSTATE  Packet // Identifies the channel’s chat settings (e.g., slow mode duration).
 	USERNOTICE Packet // Announces Twitch-specific events to the channel (e.g., a user’s subscription notification).
 	USERSTATE  Packet // Identifies a user’s chat settings or properties (e.g., chat color).
-	WHISPER    Packet
+	WHISPER    Packet // A private message to the client.
+)
+
+// ClearKind is what a CLEARCHAT cleared.
+type ClearKind int
+
+const (
+	ClearAll     ClearKind = iota // The whole chat was cleared.
+	ClearBan                      // A user was permanently banned.
+	ClearTimeout                  // A user was timed out.
 )
 
+func (k ClearKind) String() string {
+	switch k {
+	case ClearAll:
+		return "clear"
+	case ClearBan:
+		return "ban"
+	case ClearTimeout:
+		return "timeout"
+	default:
+		return "ClearKind(" + strconv.Itoa(int(k)) + ")"
+	}
+}
+
 func (p *CLEARCHAT) Channel() string { return p.Params[0][1:] }
 func (p *CLEARCHAT) Nick() string {
 	if len(p.Params) > 1 {
@@ -41,43 +79,171 @@ func (p *CLEARCHAT) Nick() string {
 	}
 	return ""
 }
+func (p *CLEARCHAT) Kind() ClearKind {
+	switch {
+	case p.Nick() == "":
+		return ClearAll
+	case p.Tags["ban-duration"] != "":
+		return ClearTimeout
+	default:
+		return ClearBan
+	}
+}
 
-func (p *CLEARMSG) Login() (string, error)       { return tagorerr(p, "login") }
-func (p *CLEARMSG) TargetMsgID() (string, error) { return tagorerr(p, "target-msg-id") }
+// Duration of a timeout, 0 for bans and clears.
+func (p *CLEARCHAT) Duration() time.Duration {
+	seconds, err := strconv.Atoi(p.Tags["ban-duration"])
+	if err != nil || p.Nick() == "" {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+func (p *CLEARCHAT) TargetUserID() (string, error) { return tagorerr(p.Tags, "target-user-id") }
+func (p *CLEARCHAT) RoomID() (string, error)       { return tagorerr(p.Tags, "room-id") }
+func (p *CLEARCHAT) Time() (time.Time, error)      { return timeorerr(p.Tags, "tmi-sent-ts") }
+
+func (p *CLEARMSG) Login() (string, error)       { return tagorerr(p.Tags, "login") }
+func (p *CLEARMSG) TargetMsgID() (string, error) { return tagorerr(p.Tags, "target-msg-id") }
 func (p *CLEARMSG) Channel() string              { return p.Params[0][1:] }
 func (p *CLEARMSG) Message() string              { return p.Params[1] }
 
 func (p *HOSTTARGET) HostingChannel() string { return p.Params[0][1:] }
-func (p *HOSTTARGET) Channel() string        { return strings.SplitN(p.Params[1], " ", 1)[0] }
-func (p *HOSTTARGET) NumViewers() (int, error) {
-	split := strings.SplitN(p.Params[1], " ", 1)
-	if len(split) > 1 {
-		return strconv.Atoi(split[1])
+
+// Target is the hosted channel, empty when hosting stopped.
+func (p *HOSTTARGET) Target() string {
+	if p.Stopped() {
+		return ""
+	}
+	return strings.Fields(p.Params[1])[0]
+}
+
+// Viewers is the number of viewers taken along to the target.
+func (p *HOSTTARGET) Viewers() int {
+	fields := strings.Fields(p.Params[1])
+	if len(fields) < 2 {
+		return 0
+	}
+	n, _ := strconv.Atoi(fields[1])
+	return n
+}
+
+// Channel is the hosted channel.
+//
+// Deprecated: Use Target, which is empty when hosting stopped.
+func (p *HOSTTARGET) Channel() string { return p.Target() }
+
+// NumViewers is the number of viewers taken along to the target.
+//
+// Deprecated: Use Viewers.
+func (p *HOSTTARGET) NumViewers() (int, error) { return p.Viewers(), nil }
+
+// Stopped reports whether the channel stopped hosting.
+func (p *HOSTTARGET) Stopped() bool {
+	fields := strings.Fields(p.Params[1])
+	return len(fields) == 0 || fields[0] == "-"
+}
+
+func (p *JOIN) Channel() string { return p.Params[0][1:] }
+func (p *JOIN) Login() string   { return p.Prefix.Nick }
+
+func (p *NAMES) Channel() string {
+	if len(p.Params) < 3 {
+		return ""
 	}
-	return 0, nil
+	return strings.TrimPrefix(p.Params[2], "#")
+}
+func (p *NAMES) Logins() []string {
+	if len(p.Params) < 4 {
+		return nil
+	}
+	return strings.Fields(p.Params[3])
 }
 
-func (p *NOTICE) Channel() string        { return p.Params[0][1:] }
-func (p *NOTICE) Message() string        { return p.Params[1] }
-func (p *NOTICE) MsgID() (string, error) { return tagorerr(p, "msg-id") }
+// Channel the notice is about, empty for global notices.
+func (p *NOTICE) Channel() string {
+	if p.Global() {
+		return ""
+	}
+	return p.Params[0][1:]
+}
 
-func (p *PRIVMSG) Channel() string { return p.Params[0][1:] }
-func (p *PRIVMSG) Message() string { return p.Params[1] }
-func (p *PRIVMSG) Author() string  { return p.Prefix.Nick }
+// Global reports whether the notice is not about a channel, such as a
+// failed login.
+func (p *NOTICE) Global() bool { return !strings.HasPrefix(p.Params[0], "#") }
+
+func (p *NOTICE) Message() string { return p.Params[1] }
+func (p *NOTICE) MsgID() (NoticeID, error) {
+	id, err := tagorerr(p.Tags, "msg-id")
+	return NoticeID(id), err
+}
+
+func (p *PART) Channel() string { return p.Params[0][1:] }
+func (p *PART) Login() string   { return p.Prefix.Nick }
+
+func (p *PRIVMSG) Channel() string     { return p.Params[0][1:] }
+func (p *PRIVMSG) Message() string     { return p.Params[1] }
+func (p *PRIVMSG) Author() string      { return p.Prefix.Nick }
+func (p *PRIVMSG) ID() (string, error) { return tagorerr(p.Tags, "id") }
+
+func (p *RAID) Channel() string              { return p.Params[0][1:] }
+func (p *RAID) Raider() (string, error)      { return tagorerr(p.Tags, "msg-param-login") }
+func (p *RAID) DisplayName() (string, error) { return tagorerr(p.Tags, "msg-param-displayName") }
+func (p *RAID) Viewers() (int, error) {
+	count, err := tagorerr(p.Tags, "msg-param-viewerCount")
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(count)
+}
 
 func (p *ROOMSTATE) Channel() string { return p.Params[0][1:] }
 
 func (p *USERNOTICE) Channel() string { return p.Params[0][1:] }
 func (p *USERNOTICE) Message() string { return p.Params[1] }
 
+// Raid returns the notice as a RAID if it announces one.
+func (p *USERNOTICE) Raid() (RAID, bool) {
+	if p.Tags["msg-id"] != "raid" {
+		return RAID{}, false
+	}
+	return RAID(*p), true
+}
+
 func (p *USERSTATE) Channel() string { return p.Params[0][1:] }
 
-func tagorerr(p interface{}, tag string) (string, error) {
-	packet := p.(*Packet)
-	if packet.Tags == nil {
+func (p *WHISPER) Login() string                { return p.Prefix.Nick }
+func (p *WHISPER) DisplayName() (string, error) { return tagorerr(p.Tags, "display-name") }
+func (p *WHISPER) UserID() (string, error)      { return tagorerr(p.Tags, "user-id") }
+func (p *WHISPER) Recipient() string            { return p.Params[0] }
+func (p *WHISPER) Message() string              { return p.Params[1] }
+func (p *WHISPER) ThreadID() (string, error)    { return tagorerr(p.Tags, "thread-id") }
+func (p *WHISPER) MessageID() (string, error)   { return tagorerr(p.Tags, "message-id") }
+func (p *WHISPER) Badges() (map[string]string, error) {
+	badges, err := tagorerr(p.Tags, "badges")
+	if err != nil {
+		return nil, err
+	}
+	return parseBadges(badges), nil
+}
+
+func tagorerr(tags map[string]string, tag string) (string, error) {
+	if tags == nil {
 		return "", errNoTagsCap
 	}
-	return packet.Tags[tag], nil
+	return tags[tag], nil
+}
+
+// timeorerr parses a tag holding a Unix timestamp in milliseconds.
+func timeorerr(tags map[string]string, tag string) (time.Time, error) {
+	value, err := tagorerr(tags, tag)
+	if err != nil {
+		return time.Time{}, err
+	}
+	ms, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, ms*int64(time.Millisecond)), nil
 }
 
 func toevent(p Packet) Event {
@@ -88,8 +254,14 @@ func toevent(p Packet) Event {
 		return CLEARMSG(p)
 	case "HOSTTARGET":
 		return HOSTTARGET(p)
+	case "JOIN":
+		return JOIN(p)
+	case "353":
+		return NAMES(p)
 	case "NOTICE":
 		return NOTICE(p)
+	case "PART":
+		return PART(p)
 	case "PING":
 		return PING(p)
 	case "PRIVMSG":
@@ -109,6 +281,18 @@ func toevent(p Packet) Event {
 	}
 }
 
+// PacketOf returns the packet an event was made from, false for events
+// such as ChannelChange that were not.
+func PacketOf(ev Event) (Packet, bool) {
+	v := reflect.ValueOf(ev)
+	if !v.IsValid() || !v.Type().ConvertibleTo(packetType) {
+		return Packet{}, false
+	}
+	return v.Convert(packetType).Interface().(Packet), true
+}
+
+var packetType = reflect.TypeOf(Packet{})
+
 const (
 	url           = "irc.chat.twitch.tv:6667"
 	urlssl        = "irc.chat.twitch.tv:6697"
@@ -119,85 +303,171 @@ const (
 // Command executes a command to the server.
 // Be wary that there is a potential for race conditions if you access
 // non-thread-safe variables.
-type Command func(io.Writer)
+type Command func(io.Writer) error
 
 // Join a twitch channel.
 func Join(channels ...string) Command {
-	// return func(w io.Writer) {
-	// 	var buf bytes.Buffer
-	// 	var size int
-	// 	buf.WriteString("JOIN #")
-	// 	buf.WriteString(channels[0])
-	// 	for _, channel := range channels[1:] {
-	// 		size += len(channel)
-	// 		if size > maxpacketsize {
-	// 			buf.WriteString(Delim)
-	// 			buf.WriteString("JOIN #")
-	// 			buf.WriteString(channel)
-	// 			size = len(channel)
-	// 			continue
-	// 		}
-	// 		buf.WriteString(",#")
-	// 		buf.WriteString(channel)
-	// 	}
-	// 	buf.WriteString(Delim)
-	// 	// err := w.Flush()
-	// 	_, err := buf.WriteTo(w)
-	// 	if err != nil {
-	// 		fmt.Println(err)
-	// 	}
-	// }
 	// TODO: split into multiple packets when exceeding IRC packet size limit.
 	//       is it even necessary? it works when you exceed 512.
-	return Line("JOIN #" + strings.Join(channels, ",#"))
+	names := make([]string, len(channels))
+	for i, channel := range channels {
+		name, err := normalizeChannel(channel)
+		if err != nil {
+			return fail(err)
+		}
+		names[i] = name
+	}
+	if len(names) == 0 {
+		return fail(fmt.Errorf("%w: no channels", ErrInvalidChannel))
+	}
+	return Line("JOIN #" + strings.Join(names, ",#"))
 }
 
 // Part from a twitch channel.
-func Part(channel string) Command { return Line("PART #" + channel) }
+func Part(channel string) Command {
+	name, err := normalizeChannel(channel)
+	if err != nil {
+		return fail(err)
+	}
+	return Line("PART #" + name)
+}
 
 // Say something in a channel.
+// With BypassDuplicates, saying the same thing twice in a row is allowed.
 func Say(channel, message string) Command {
-	return Line("PRIVMSG #" + channel + " :" + message)
+	name, err := normalizeChannel(channel)
+	if err != nil {
+		return fail(err)
+	}
+	return Line("PRIVMSG #" + name + " :" + message)
+}
+
+// Whisper sends a private message to a user.
+// Whispers have their own rate limits, see WhisperRateLimit.
+func Whisper(user, message string) Command {
+	login, err := normalizeLogin(user)
+	if err != nil {
+		return fail(err)
+	}
+	return Line("PRIVMSG #jtv :/w " + login + " " + message)
 }
 
 // Pong is a reply to PING.
-func Pong() Command { return Line("PONG :tmi.twitch.tv") }
+func Pong() Command { return Line(pongLine) }
+
+const pongLine = "PONG :tmi.twitch.tv"
 
 // Line writes a line to the server.
+// The line must not contain CR, LF or NUL, as those would allow smuggling
+// additional commands onto the wire.
 func Line(packet string) Command {
-	return func(w io.Writer) {
-		fmt.Println("->", packet)
-		w.Write(append([]byte(packet), Delim...))
+	return func(w io.Writer) error {
+		if err := checkLine(packet); err != nil {
+			return err
+		}
+		_, err := w.Write(append([]byte(packet), Delim...))
+		return err
 	}
 }
 
+// fail is a command that writes nothing and reports err.
+func fail(err error) Command {
+	return func(io.Writer) error { return err }
+}
+
+func checkLine(packet string) error {
+	if i := strings.IndexAny(packet, "\r\n\x00"); i >= 0 {
+		return fmt.Errorf("%w: %q at byte %d", ErrInvalidLine, packet[i], i)
+	}
+	return nil
+}
+
+// normalizeChannel lowercases a channel name and strips a leading '#'.
+func normalizeChannel(channel string) (string, error) {
+	name := strings.ToLower(strings.TrimPrefix(channel, "#"))
+	if !validName(name) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidChannel, channel)
+	}
+	return name, nil
+}
+
+// normalizeLogin lowercases a user login.
+func normalizeLogin(user string) (string, error) {
+	login := strings.ToLower(user)
+	if !validName(login) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidUser, user)
+	}
+	return login, nil
+}
+
+func validName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, " ,#:\r\n\x00")
+}
+
 type Client struct {
-	conn         *net.TCPConn
+	dropped      int64 // first for 64-bit alignment of atomic operations
+	addr         string
+	dial         func(ctx context.Context, network, addr string) (net.Conn, error)
+	websocket    bool
+	conn         net.Conn
+	readConn     net.Conn // with SplitConnections, the anonymous connection
+	split        bool
+	readers      sync.WaitGroup
 	nick, pass   string
 	capabilities []string
 	events       chan Event
+	eventBuffer  int
+	eventPolicy  EventPolicy
+	spill        *eventQueue
 	commands     chan Command
+	requests     chan request
+	pongs        chan connRole // PINGs to answer on a connection of SplitConnections
+	closed       chan struct{}
+	limiter      *limiter
+	whispers     *whisperLimiter
+	tracker      *tracker
+	handlers     []Handler
+	moderator    Moderator
+	recorder     *Recorder
+	log          io.Writer
+
+	mu               sync.Mutex
+	channels         map[string]*channelState
+	lastSent         map[string]sentMessage // by channel
+	bypassDuplicates bool
 }
 
+// Handler is given every event before it is delivered on Events().
+// Handle is called from the read loop and must not block.
+type Handler interface {
+	Handle(Event)
+}
+
+// Env is where bots send commands and receive events. It is implemented by
+// Client, Mux, and Pool.Env, so bot code can run unchanged on a single
+// connection, several of them, or a test fake.
 type Env interface {
 	Command() chan<- Command
 	Events() <-chan Event
 }
 
-type Mux struct {
-	commands chan Command
-	events   chan Event
-}
+var _ Env = (*Client)(nil)
 
-func (m *Mux) Command() chan<- Command { return m.commands }
-func (m *Mux) Events() <-chan Event    { return m.events }
+// Credentials of an anonymous, read-only user.
+const (
+	anonNick = "justinfan77777"
+	anonPass = "oauth:ThisIsAnAnonymousAuth_forsenPls"
+)
 
 func NewClient(options ...Option) (*Client, error) {
-	var c Client
+	c := Client{tracker: newTracker()}
 
 	// Set default options
-	Auth("justinfan77777", "oauth:ThisIsAnAnonymousAuth_forsenPls")(&c)
+	Log(os.Stdout)(&c)
+	Dialer((&net.Dialer{}).DialContext)(&c)
+	Auth(anonNick, anonPass)(&c)
 	Cap(CapCommands, CapMembership, CapTags)(&c)
+	WhisperRateLimit(3, 100, 40)(&c)
 
 	for _, option := range options {
 		option(&c)
@@ -208,73 +478,198 @@ func NewClient(options ...Option) (*Client, error) {
 
 // Connect to Twitch chat.
 func (c *Client) Connect() error {
-	var err error
-
-	addr, err := net.ResolveTCPAddr("tcp", url)
+	ctx := context.Background()
+	conn, err := c.dialServer(ctx)
 	if err != nil {
 		return err
 	}
-
-	c.conn, err = net.DialTCP("tcp", nil, addr)
+	c.conn = conn
+	if !c.split {
+		c.start(c.conn, nil)
+		return nil
+	}
+	readConn, err := c.dialServer(ctx)
 	if err != nil {
+		conn.Close()
 		return err
 	}
+	handshake := "PASS " + anonPass + Delim +
+		"NICK " + anonNick + Delim +
+		"CAP REQ :" + strings.Join(c.capabilities, " ") + Delim
+	if _, err := io.WriteString(readConn, handshake); err != nil {
+		conn.Close()
+		readConn.Close()
+		return err
+	}
+	c.readConn = readConn
+	c.start(c.conn, c.readConn)
+	return nil
+}
 
-	// TODO: should these be buffered?
-	c.events = make(chan Event)
-	c.commands = make(chan Command)
-
-	go func() {
-		conn := c.conn
-		events := c.events
-		r := bufio.NewReader(conn)
-		for {
-			line, _, err := r.ReadLine() // TODO: can packets contain \n without \r?
-			if err != nil {
-				if err == io.EOF {
-					break
-				}
-				panic(err)
-			}
-			fmt.Println("<-", string(line))
-			p, err := parsePacket(line)
-			if err != nil {
-				// just log it for now, not sure what to do here 🤔
-				fmt.Println("failed to parse packet: ", err)
-				continue
-			}
-			if len(events) == cap(events)-1 {
-				fmt.Println("events channel is full, about to block")
-			}
-			events <- toevent(p)
+// dialServer connects to the server, over WebSocket if set.
+func (c *Client) dialServer(ctx context.Context) (net.Conn, error) {
+	addr := c.addr
+	if c.websocket {
+		if addr == "" {
+			addr = urlws
 		}
-		fmt.Println("!!!!!!!!!!!!! exited read loop")
-	}()
+		return dialWebSocket(ctx, c.dial, addr)
+	}
+	if addr == "" {
+		addr = url
+	}
+	return c.dial(ctx, "tcp", addr)
+}
 
+// connRole is what a connection is used for.
+type connRole int
+
+const (
+	readWrite connRole = iota // the only connection
+	readOnly                  // the anonymous connection of SplitConnections
+	writeOnly                 // the authenticated connection of SplitConnections
+)
+
+// start the read and write loops on conn and perform the handshake. If
+// readConn is not nil, events are read from it instead, and conn is only
+// read for what concerns the authenticated user.
+func (c *Client) start(conn, readConn io.ReadWriter) {
+	c.events = make(chan Event, c.eventBuffer)
+	c.commands = make(chan Command)
+	c.requests = make(chan request)
+	c.pongs = make(chan connRole)
+	c.closed = make(chan struct{})
+
+	if readConn == nil {
+		c.readers.Add(1)
+		go c.readLoop(conn, readWrite)
+		go c.writeLoop(conn, nil)
+	} else {
+		c.readers.Add(2)
+		go c.readLoop(readConn, readOnly)
+		go c.readLoop(conn, writeOnly)
+		go c.writeLoop(conn, readConn)
+	}
+	events := c.events
+	if c.eventPolicy == Spill {
+		c.spill = newEventQueue()
+		go c.pump(c.spill, events)
+	}
+	spill := c.spill
 	go func() {
-		conn := c.conn
-		commands := c.commands
-		w := bufio.NewWriter(conn)
-		// w := io.MultiWriter(conn, NewPrefixer(os.Stdout, func() string { return "-> " }))
-
-		for command := range commands {
-			command(w)
-			w.Flush()
+		c.readers.Wait()
+		c.tracker.close()
+		if spill != nil {
+			spill.close()
+		} else {
+			close(events)
 		}
-		fmt.Println("!!!!!!!!!!!!! exited write loop")
 	}()
 
 	c.commands <- Line("PASS " + c.pass)
 	c.commands <- Line("NICK " + c.nick)
 	c.commands <- Line("CAP REQ :" + strings.Join(c.capabilities, " "))
+}
 
-	return nil
+func (c *Client) readLoop(conn io.Reader, role connRole) {
+	defer c.readers.Done()
+	if role != readWrite {
+		// One connection is of no use without the other.
+		defer c.disconnect()
+	}
+	r := bufio.NewReader(conn)
+	for {
+		line, _, err := r.ReadLine() // TODO: can packets contain \n without \r?
+		if err != nil {
+			select {
+			case <-c.closed:
+				// The connection was closed by Close.
+				return
+			default:
+			}
+			if err != io.EOF && role == readWrite {
+				c.logln("read failed: ", err)
+			}
+			break
+		}
+		c.logln("<-", string(line))
+		c.record(Inbound, line)
+		p, err := parsePacket(line)
+		if err != nil {
+			// just log it for now, not sure what to do here 🤔
+			c.logln("failed to parse packet: ", err)
+			continue
+		}
+		if role == readOnly && p.Command == "USERSTATE" {
+			// The anonymous user's state, the authenticated one's is
+			// read from the other connection.
+			continue
+		}
+		if role != readWrite && p.Command == "PING" {
+			select {
+			case c.pongs <- role:
+			case <-c.closed:
+				return
+			}
+			continue
+		}
+		derived := c.handle(p, role)
+		if role != writeOnly || personal(p) {
+			derived = append([]Event{toevent(p)}, derived...)
+		}
+		for _, ev := range derived {
+			for _, h := range c.handlers {
+				h.Handle(ev)
+			}
+			if !c.deliver(ev) {
+				return
+			}
+		}
+	}
 }
 
-// Close the connection.
+// personal reports whether p is addressed to the authenticated user, and
+// so is only received on the authenticated connection.
+func personal(p Packet) bool {
+	switch p.Command {
+	case "USERSTATE", "NOTICE", "WHISPER":
+		return true
+	}
+	return false
+}
+
+// disconnect closes every connection of the client.
+func (c *Client) disconnect() {
+	c.conn.Close()
+	if c.readConn != nil {
+		c.readConn.Close()
+	}
+}
+
+// handle updates the client's own bookkeeping with an incoming packet,
+// returning any events derived from it.
+func (c *Client) handle(p Packet, role connRole) []Event {
+	var events []Event
+	if p.Command == "JOIN" && len(p.Params) > 0 && strings.EqualFold(p.Prefix.Nick, c.nick) {
+		c.tracker.joined(strings.TrimPrefix(p.Params[0], "#"))
+	}
+	if role != readOnly {
+		// Nothing is sent on the anonymous connection.
+		c.tracker.received(p)
+	}
+	if change := c.track(p); change != nil {
+		events = append(events, *change)
+	}
+	return events
+}
+
+// Close the connection. Events() is closed once the read loop has exited.
 func (c *Client) Close() error {
+	close(c.closed)
 	close(c.commands)
-	close(c.events)
+	if c.readConn != nil {
+		c.readConn.Close()
+	}
 	return c.conn.Close()
 }
 
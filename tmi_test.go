@@ -0,0 +1,355 @@
+package tmi
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi/tmitest"
+)
+
+func TestCommands(t *testing.T) {
+	tests := []struct {
+		name    string
+		command Command
+		want    string
+		wantErr error
+	}{
+		{"join", Join("nymn"), "JOIN #nymn\r\n", nil},
+		{"join many", Join("nymn", "#Pajlada"), "JOIN #nymn,#pajlada\r\n", nil},
+		{"join none", Join(), "", ErrInvalidChannel},
+		{"join space", Join("nymn forsen"), "", ErrInvalidChannel},
+		{"join comma", Join("nymn,forsen"), "", ErrInvalidChannel},
+		{"part", Part("#NymN"), "PART #nymn\r\n", nil},
+		{"part empty", Part("#"), "", ErrInvalidChannel},
+		{"say", Say("#Nymn", "nobody knows"), "PRIVMSG #nymn :nobody knows\r\n", nil},
+		{"say crlf", Say("nymn", "hi\r\nPRIVMSG #other :spam"), "", ErrInvalidLine},
+		{"say lf", Say("nymn", "hi\nJOIN #other"), "", ErrInvalidLine},
+		{"say nul", Say("nymn", "hi\x00"), "", ErrInvalidLine},
+		{"say bad channel", Say("nymn\r\n", "hi"), "", ErrInvalidChannel},
+		{"whisper", Whisper("Forsen", "hi there"), "PRIVMSG #jtv :/w forsen hi there\r\n", nil},
+		{"whisper bad user", Whisper("for sen", "hi"), "", ErrInvalidUser},
+		{"line", Line("PING :tmi.twitch.tv"), "PING :tmi.twitch.tv\r\n", nil},
+		{"line cr", Line("PING\r"), "", ErrInvalidLine},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := tt.command(&buf)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("wrote %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCLEARCHAT(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		kind     ClearKind
+		nick     string
+		duration time.Duration
+		userID   string
+	}{
+		{
+			name: "clear",
+			line: "@room-id=12345678;tmi-sent-ts=1642715695392 :tmi.twitch.tv CLEARCHAT #dallas",
+			kind: ClearAll,
+		},
+		{
+			name:   "ban",
+			line:   "@room-id=12345678;target-user-id=87654321;tmi-sent-ts=1642715756806 :tmi.twitch.tv CLEARCHAT #dallas :ronni",
+			kind:   ClearBan,
+			nick:   "ronni",
+			userID: "87654321",
+		},
+		{
+			name:     "timeout",
+			line:     "@ban-duration=350;room-id=12345678;target-user-id=87654321;tmi-sent-ts=1642719320727 :tmi.twitch.tv CLEARCHAT #dallas :ronni",
+			kind:     ClearTimeout,
+			nick:     "ronni",
+			duration: 350 * time.Second,
+			userID:   "87654321",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := parsePacket([]byte(tt.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev := toevent(p).(CLEARCHAT)
+			if got := ev.Kind(); got != tt.kind {
+				t.Errorf("Kind() = %v, want %v", got, tt.kind)
+			}
+			if got := ev.Nick(); got != tt.nick {
+				t.Errorf("Nick() = %q, want %q", got, tt.nick)
+			}
+			if got := ev.Duration(); got != tt.duration {
+				t.Errorf("Duration() = %v, want %v", got, tt.duration)
+			}
+			if got, _ := ev.TargetUserID(); got != tt.userID {
+				t.Errorf("TargetUserID() = %q, want %q", got, tt.userID)
+			}
+			if got, _ := ev.RoomID(); got != "12345678" {
+				t.Errorf("RoomID() = %q", got)
+			}
+			if got, err := ev.Time(); err != nil || got.Year() != 2022 {
+				t.Errorf("Time() = %v, %v", got, err)
+			}
+		})
+	}
+
+	ev := CLEARCHAT(Packet{Command: "CLEARCHAT", Params: []string{"#dallas", "ronni"}})
+	if _, err := ev.RoomID(); err != errNoTagsCap {
+		t.Errorf("RoomID() without tags = %v, want %v", err, errNoTagsCap)
+	}
+}
+
+func TestHOSTTARGET(t *testing.T) {
+	tests := []struct {
+		line    string
+		target  string
+		viewers int
+		stopped bool
+	}{
+		{":tmi.twitch.tv HOSTTARGET #abc :xyz 10", "xyz", 10, false},
+		{":tmi.twitch.tv HOSTTARGET #abc :xyz", "xyz", 0, false},
+		{":tmi.twitch.tv HOSTTARGET #abc :- 10", "", 10, true},
+		{":tmi.twitch.tv HOSTTARGET #abc :-", "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			p, err := parsePacket([]byte(tt.line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ev := toevent(p).(HOSTTARGET)
+			if got := ev.HostingChannel(); got != "abc" {
+				t.Errorf("HostingChannel() = %q, want %q", got, "abc")
+			}
+			if got := ev.Target(); got != tt.target {
+				t.Errorf("Target() = %q, want %q", got, tt.target)
+			}
+			if got := ev.Viewers(); got != tt.viewers {
+				t.Errorf("Viewers() = %d, want %d", got, tt.viewers)
+			}
+			if got := ev.Stopped(); got != tt.stopped {
+				t.Errorf("Stopped() = %v, want %v", got, tt.stopped)
+			}
+		})
+	}
+}
+
+func TestRAID(t *testing.T) {
+	p, err := parsePacket([]byte(`@badge-info=;badges=turbo/1;color=#9ACD32;display-name=TestChannel;emotes=;id=3d830f12-795c-447d-af3c-ea05e40fbddb;login=testchannel;mod=0;msg-id=raid;msg-param-displayName=TestChannel;msg-param-login=testchannel;msg-param-viewerCount=15;room-id=33332222;subscriber=0;system-msg=15\sraiders\sfrom\sTestChannel\shave\sjoined\n!;tmi-sent-ts=1507246572675;turbo=1;user-id=123456;user-type= :tmi.twitch.tv USERNOTICE #othertestchannel`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	notice, ok := toevent(p).(USERNOTICE)
+	if !ok {
+		t.Fatalf("toevent() = %T, want USERNOTICE", toevent(p))
+	}
+	ev, ok := notice.Raid()
+	if !ok {
+		t.Fatal("Raid() = false")
+	}
+	if got := ev.Channel(); got != "othertestchannel" {
+		t.Errorf("Channel() = %q", got)
+	}
+	if got, _ := ev.Raider(); got != "testchannel" {
+		t.Errorf("Raider() = %q", got)
+	}
+	if got, _ := ev.DisplayName(); got != "TestChannel" {
+		t.Errorf("DisplayName() = %q", got)
+	}
+	if got, err := ev.Viewers(); err != nil || got != 15 {
+		t.Errorf("Viewers() = %d, %v", got, err)
+	}
+}
+
+func TestWHISPER(t *testing.T) {
+	p, err := parsePacket([]byte("@badges=turbo/1;color=#9ACD32;display-name=PetsgomOO;emotes=;message-id=306;thread-id=12345678_87654321;turbo=1;user-id=87654321;user-type= :petsgomoo!petsgomoo@petsgomoo.tmi.twitch.tv WHISPER foo :hello there"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := toevent(p).(WHISPER)
+	if got := ev.Login(); got != "petsgomoo" {
+		t.Errorf("Login() = %q", got)
+	}
+	if got, _ := ev.DisplayName(); got != "PetsgomOO" {
+		t.Errorf("DisplayName() = %q", got)
+	}
+	if got, _ := ev.UserID(); got != "87654321" {
+		t.Errorf("UserID() = %q", got)
+	}
+	if got := ev.Recipient(); got != "foo" {
+		t.Errorf("Recipient() = %q", got)
+	}
+	if got := ev.Message(); got != "hello there" {
+		t.Errorf("Message() = %q", got)
+	}
+	if got, _ := ev.ThreadID(); got != "12345678_87654321" {
+		t.Errorf("ThreadID() = %q", got)
+	}
+	if got, _ := ev.MessageID(); got != "306" {
+		t.Errorf("MessageID() = %q", got)
+	}
+	if got, _ := ev.Badges(); got["turbo"] != "1" || len(got) != 1 {
+		t.Errorf("Badges() = %v", got)
+	}
+}
+
+func TestDialer(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+
+	var dialed string
+	c, _ := NewClient(
+		Address("irc.example.com:6667"),
+		Dialer(func(ctx context.Context, network, addr string) (net.Conn, error) {
+			dialed = network + " " + addr
+			var d net.Dialer
+			return d.DialContext(ctx, network, s.Addr)
+		}),
+	)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if dialed != "tcp irc.example.com:6667" {
+		t.Errorf("dialed %q", dialed)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := s.NextConn(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSplitConnections(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	c, _ := NewClient(Address(s.Addr), Auth("bot", "oauth:secret"), SplitConnections)
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var reader, writer *tmitest.Conn
+	for i := 0; i < 2; i++ {
+		conn, err := s.NextConn(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if conn.Nick() == "bot" {
+			writer = conn
+		} else {
+			reader = conn
+		}
+	}
+	if reader == nil || writer == nil || reader.Pass() == "oauth:secret" {
+		t.Fatalf("want one anonymous and one authenticated connection")
+	}
+
+	// Read events in the background, the authenticated connection
+	// blocks on delivering its USERSTATEs.
+	events := make(chan Event, 100)
+	go func() {
+		for ev := range c.Events() {
+			events <- ev
+		}
+		close(events)
+	}()
+
+	if err := c.Send(ctx, Join("chan")); err != nil {
+		t.Fatal(err)
+	}
+	var got []Event
+	for ev := range events {
+		got = append(got, ev)
+		if _, ok := ev.(ROOMSTATE); ok {
+			break
+		}
+	}
+	for _, conn := range []*tmitest.Conn{reader, writer} {
+		if channels := conn.Channels(); len(channels) != 1 || channels[0] != "chan" {
+			t.Errorf("%s joined %v", conn.Nick(), channels)
+		}
+	}
+	for _, ev := range got {
+		if join, ok := ev.(JOIN); ok && join.Login() != anonNick {
+			t.Errorf("got the authenticated connection's JOIN")
+		}
+	}
+
+	// Each connection answers its own PINGs.
+	reader.Send("PING :tmi.twitch.tv")
+	writer.Send("PING :tmi.twitch.tv")
+	for _, conn := range []*tmitest.Conn{reader, writer} {
+		if n := waitPongs(conn, 1); n != 1 {
+			t.Errorf("%s sent %d PONGs, want 1", conn.Nick(), n)
+		}
+	}
+
+	if err := c.Deliver(ctx, Say("chan", "hello")); err != nil {
+		t.Fatal(err)
+	}
+	if state, _ := c.Channel("chan"); state.DisplayName != "bot" {
+		t.Errorf("DisplayName = %q, want the authenticated user's", state.DisplayName)
+	}
+	for _, line := range reader.Received() {
+		if strings.HasPrefix(line, "PRIVMSG") {
+			t.Errorf("reader sent %q", line)
+		}
+	}
+
+	writer.Send(
+		":someone!someone@someone.tmi.twitch.tv PRIVMSG #chan :seen twice",
+		":someone!someone@someone.tmi.twitch.tv WHISPER bot :psst",
+	)
+	for ev := range events {
+		switch ev := ev.(type) {
+		case WHISPER:
+			reader.Send(":someone!someone@someone.tmi.twitch.tv PRIVMSG #chan :seen once")
+		case PRIVMSG:
+			if ev.Message() == "seen twice" {
+				t.Fatalf("got PRIVMSG from the authenticated connection")
+			}
+			return
+		}
+	}
+	t.Fatal("events closed")
+}
+
+// waitPongs waits a moment for conn to send n PONGs, and returns how many
+// it sent.
+func waitPongs(conn *tmitest.Conn, n int) int {
+	count := func() int {
+		var pongs int
+		for _, line := range conn.Received() {
+			if strings.HasPrefix(line, "PONG ") {
+				pongs++
+			}
+		}
+		return pongs
+	}
+	deadline := time.Now().Add(time.Second)
+	for count() < n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give an extra one time to arrive.
+	time.Sleep(50 * time.Millisecond)
+	return count()
+}
@@ -0,0 +1,370 @@
+// Package tmitest provides a fake Twitch IRC server for testing code built
+// on tmi.Client without connecting to Twitch.
+//
+//	s := tmitest.NewServer()
+//	defer s.Close()
+//	c, _ := tmi.NewClient(tmi.Address(s.Addr))
+//	c.Connect()
+//	conn, _ := s.NextConn(ctx)
+//	conn.Send(":foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :hello")
+package tmitest
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Twitch IRC server listening on the loopback interface.
+// It performs the PASS/NICK/CAP handshake, answers PING, JOIN, PART and
+// PRIVMSG like Twitch does, and records everything clients send.
+type Server struct {
+	Addr string // host:port to pass to tmi.Address.
+
+	ln net.Listener
+
+	mu     sync.Mutex
+	ready  *sync.Cond // signalled when a connection is added to conns
+	conns  []*Conn    // handshaken but not yet returned by NextConn
+	limit  int
+	window time.Duration
+	all    []*Conn
+	closed bool
+}
+
+// NewServer starts a server. It enforces Twitch's limit of 20 messages per
+// 30 seconds by default.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("tmitest: failed to listen: %v", err))
+	}
+	s := &Server{
+		Addr:   ln.Addr().String(),
+		ln:     ln,
+		limit:  20,
+		window: 30 * time.Second,
+	}
+	s.ready = sync.NewCond(&s.mu)
+	go s.serve()
+	return s
+}
+
+// RateLimit makes the server reject PRIVMSGs beyond n per window with a
+// msg_ratelimit NOTICE. n <= 0 disables the limit.
+func (s *Server) RateLimit(n int, window time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limit, s.window = n, window
+}
+
+// NextConn waits for the next client to complete the handshake.
+func (s *Server) NextConn(ctx context.Context) (*Conn, error) {
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// Wake up the wait below to return the error.
+			s.mu.Lock()
+			s.ready.Broadcast()
+			s.mu.Unlock()
+		case <-stop:
+		}
+	}()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for len(s.conns) == 0 {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		s.ready.Wait()
+	}
+	c := s.conns[0]
+	s.conns = s.conns[1:]
+	return c, nil
+}
+
+// Close stops the server and disconnects every client.
+func (s *Server) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	conns := s.all
+	s.mu.Unlock()
+
+	err := s.ln.Close()
+	for _, c := range conns {
+		c.Disconnect()
+	}
+	return err
+}
+
+func (s *Server) serve() {
+	for {
+		nc, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			nc.Close()
+			return
+		}
+		c := &Conn{
+			server:   s,
+			conn:     nc,
+			lines:    make(chan string, 1024),
+			channels: make(map[string]bool),
+		}
+		s.all = append(s.all, c)
+		s.mu.Unlock()
+		go c.serve()
+	}
+}
+
+// Conn is a client connected to the server.
+type Conn struct {
+	server *Server
+	conn   net.Conn
+	lines  chan string // received after the handshake, except PASS, NICK and CAP
+
+	mu       sync.Mutex
+	wmu      sync.Mutex
+	nick     string
+	pass     string
+	caps     []string
+	received []string
+	channels map[string]bool
+	sent     []time.Time
+}
+
+// Nick the client logged in with.
+func (c *Conn) Nick() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.nick
+}
+
+// Pass the client logged in with.
+func (c *Conn) Pass() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pass
+}
+
+// Caps the client requested.
+func (c *Conn) Caps() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.caps...)
+}
+
+// Channels the client has joined.
+func (c *Conn) Channels() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var channels []string
+	for channel := range c.channels {
+		channels = append(channels, channel)
+	}
+	return channels
+}
+
+// Received returns every line the client sent, including the handshake.
+func (c *Conn) Received() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.received...)
+}
+
+// Next waits for the next line the client sends after the handshake.
+func (c *Conn) Next(ctx context.Context) (string, error) {
+	select {
+	case line, ok := <-c.lines:
+		if !ok {
+			return "", fmt.Errorf("tmitest: client disconnected")
+		}
+		return line, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// Send a line to the client, such as a PRIVMSG, NOTICE or RECONNECT.
+func (c *Conn) Send(lines ...string) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	for _, line := range lines {
+		if _, err := c.conn.Write([]byte(line + "\r\n")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Disconnect the client, as if the server went away.
+func (c *Conn) Disconnect() error {
+	return c.conn.Close()
+}
+
+func (c *Conn) serve() {
+	defer close(c.lines)
+	defer c.conn.Close()
+	r := bufio.NewScanner(c.conn)
+	handshake := true
+	for r.Scan() {
+		line := r.Text()
+		c.mu.Lock()
+		c.received = append(c.received, line)
+		c.mu.Unlock()
+
+		command, params := split(line)
+		switch command {
+		case "PASS":
+			c.mu.Lock()
+			c.pass = param(params, 0)
+			c.mu.Unlock()
+			continue
+		case "CAP":
+			c.mu.Lock()
+			c.caps = append(c.caps, strings.Fields(param(params, 1))...)
+			c.mu.Unlock()
+			c.Send(":tmi.twitch.tv CAP * ACK :" + param(params, 1))
+			continue
+		case "NICK":
+			if !handshake {
+				continue
+			}
+			nick := strings.ToLower(param(params, 0))
+			c.mu.Lock()
+			c.nick = nick
+			c.mu.Unlock()
+			c.Send(
+				":tmi.twitch.tv 001 "+nick+" :Welcome, GLHF!",
+				":tmi.twitch.tv 002 "+nick+" :Your host is tmi.twitch.tv",
+				":tmi.twitch.tv 003 "+nick+" :This server is rather new",
+				":tmi.twitch.tv 004 "+nick+" :-",
+				":tmi.twitch.tv 375 "+nick+" :-",
+				":tmi.twitch.tv 372 "+nick+" :You are in a maze of twisty passages, all alike.",
+				":tmi.twitch.tv 376 "+nick+" :>",
+			)
+			handshake = false
+			// Never held up by the test, however many connect.
+			c.server.mu.Lock()
+			c.server.conns = append(c.server.conns, c)
+			c.server.ready.Broadcast()
+			c.server.mu.Unlock()
+			continue
+		}
+		if handshake {
+			continue
+		}
+
+		c.respond(command, params)
+		select {
+		case c.lines <- line:
+		default:
+			// Nobody is reading, drop it; it is still in Received.
+		}
+	}
+}
+
+// respond answers a line like Twitch would.
+func (c *Conn) respond(command string, params []string) {
+	nick := c.Nick()
+	prefix := ":" + nick + "!" + nick + "@" + nick + ".tmi.twitch.tv "
+	switch command {
+	case "PING":
+		c.Send(":tmi.twitch.tv PONG tmi.twitch.tv :" + param(params, 0))
+	case "JOIN":
+		for _, channel := range strings.Split(param(params, 0), ",") {
+			c.mu.Lock()
+			c.channels[strings.TrimPrefix(channel, "#")] = true
+			c.mu.Unlock()
+			c.Send(
+				prefix+"JOIN "+channel,
+				":"+nick+".tmi.twitch.tv 353 "+nick+" = "+channel+" :"+nick,
+				":"+nick+".tmi.twitch.tv 366 "+nick+" "+channel+" :End of /NAMES list",
+				"@badge-info=;badges=;color=;display-name="+nick+";emote-sets=0;mod=0;subscriber=0;user-type= :tmi.twitch.tv USERSTATE "+channel,
+				"@emote-only=0;followers-only=-1;r9k=0;room-id=1;slow=0;subs-only=0 :tmi.twitch.tv ROOMSTATE "+channel,
+			)
+		}
+	case "PART":
+		channel := param(params, 0)
+		c.mu.Lock()
+		delete(c.channels, strings.TrimPrefix(channel, "#"))
+		c.mu.Unlock()
+		c.Send(prefix + "PART " + channel)
+	case "PRIVMSG":
+		channel := param(params, 0)
+		if channel == "#jtv" {
+			return
+		}
+		if !c.allow() {
+			c.Send("@msg-id=msg_ratelimit :tmi.twitch.tv NOTICE " + channel + " :Your message was not sent because you are sending messages too quickly.")
+			return
+		}
+		c.Send("@badge-info=;badges=;color=;display-name=" + nick + ";emote-sets=0;mod=0;subscriber=0;user-type= :tmi.twitch.tv USERSTATE " + channel)
+	}
+}
+
+// allow reports whether a message is within the server's rate limit.
+func (c *Conn) allow() bool {
+	c.server.mu.Lock()
+	limit, window := c.server.limit, c.server.window
+	c.server.mu.Unlock()
+	if limit <= 0 {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	i := 0
+	for i < len(c.sent) && now.Sub(c.sent[i]) >= window {
+		i++
+	}
+	c.sent = c.sent[i:]
+	if len(c.sent) >= limit {
+		return false
+	}
+	c.sent = append(c.sent, now)
+	return true
+}
+
+// split splits a client line into its command and params, the last of
+// which may be a trailing param.
+func split(line string) (string, []string) {
+	var trailing *string
+	if i := strings.Index(line, " :"); i >= 0 {
+		t := line[i+2:]
+		trailing = &t
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return "", nil
+	}
+	params := fields[1:]
+	if trailing != nil {
+		params = append(params, *trailing)
+	}
+	return strings.ToUpper(fields[0]), params
+}
+
+func param(params []string, i int) string {
+	if i < len(params) {
+		return params[i]
+	}
+	return ""
+}
@@ -0,0 +1,115 @@
+package tmitest_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fourst4r/tmi"
+	"github.com/fourst4r/tmi/tmitest"
+)
+
+func TestServer(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	s.RateLimit(1, time.Minute)
+
+	c, err := tmi.NewClient(tmi.Address(s.Addr), tmi.Auth("Bot", "oauth:secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, err := s.NextConn(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.Nick() != "bot" || conn.Pass() != "oauth:secret" {
+		t.Errorf("logged in as %q, %q", conn.Nick(), conn.Pass())
+	}
+	if want := []string{tmi.CapCommands, tmi.CapMembership, tmi.CapTags}; !reflect.DeepEqual(conn.Caps(), want) {
+		t.Errorf("Caps() = %v, want %v", conn.Caps(), want)
+	}
+
+	events := make(chan tmi.Event, 100)
+	go func() {
+		for ev := range c.Events() {
+			events <- ev
+		}
+		close(events)
+	}()
+
+	if err := c.Send(ctx, tmi.Join("bar")); err != nil {
+		t.Fatal(err)
+	}
+	if line, err := conn.Next(ctx); err != nil || line != "JOIN #bar" {
+		t.Fatalf("Next() = %q, %v", line, err)
+	}
+
+	if err := c.Deliver(ctx, tmi.Say("bar", "hello")); err != nil {
+		t.Fatalf("Deliver() = %v", err)
+	}
+	var notice *tmi.NoticeError
+	if err := c.Deliver(ctx, tmi.Say("bar", "again")); !errors.As(err, &notice) || notice.MsgID != tmi.NoticeMsgRatelimit {
+		t.Fatalf("Deliver() over the limit = %v, want msg_ratelimit", err)
+	}
+
+	conn.Send(":foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :hi bot")
+	for ev := range events {
+		if msg, ok := ev.(tmi.PRIVMSG); ok {
+			if msg.Author() != "foo" || msg.Message() != "hi bot" {
+				t.Errorf("got PRIVMSG %v", msg)
+			}
+			break
+		}
+	}
+
+	conn.Disconnect()
+	for range events {
+	}
+	if got := conn.Received(); len(got) < 6 || got[0] != "PASS oauth:secret" {
+		t.Errorf("Received() = %q", got)
+	}
+}
+
+func TestServerManyConns(t *testing.T) {
+	s := tmitest.NewServer()
+	defer s.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Connections are served before the test asks for them.
+	const n = 20
+	for i := 0; i < n; i++ {
+		c, _ := tmi.NewClient(tmi.Address(s.Addr), tmi.Auth("bot", "oauth:secret"), tmi.Log(ioutil.Discard))
+		if err := c.Connect(); err != nil {
+			t.Fatal(err)
+		}
+		defer c.Close()
+		go func() {
+			for range c.Events() {
+			}
+		}()
+		if err := c.Deliver(ctx, tmi.Say("bar", "hello")); err != nil {
+			t.Fatalf("Deliver() on connection %d = %v", i, err)
+		}
+	}
+	for i := 0; i < n; i++ {
+		if _, err := s.NextConn(ctx); err != nil {
+			t.Fatalf("NextConn() %d = %v", i, err)
+		}
+	}
+	expired, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.NextConn(expired); err != context.DeadlineExceeded {
+		t.Errorf("NextConn() without a connection = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
@@ -0,0 +1,178 @@
+package tmi
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ackTimeout is how long a message waits for the server's response before
+// it is considered lost. Anonymous connections never get one.
+const ackTimeout = 30 * time.Second
+
+// ErrNoAck is reported when the server never acknowledged a message.
+var ErrNoAck = errors.New("tmi: message not acknowledged")
+
+// NoticeError is a message rejected by the server with a NOTICE, e.g.
+// msg_duplicate or msg_ratelimit.
+type NoticeError struct {
+	Channel string
+	MsgID   NoticeID
+	Message string
+}
+
+func (e *NoticeError) Error() string {
+	return "tmi: #" + e.Channel + ": " + string(e.MsgID) + ": " + e.Message
+}
+
+type delivery struct {
+	result  *Result // nil for commands sent through Command()
+	command bool    // a chat command such as /ban, answered by NOTICE if at all
+	sent    time.Time
+	timer   *time.Timer
+}
+
+// tracker matches outgoing PRIVMSGs to the USERSTATE or NOTICE the server
+// answers with. Twitch answers messages to a channel in order, so a FIFO
+// per channel is enough. Chat commands are queued too, so that the NOTICE
+// answering one isn't taken for the answer to a message.
+type tracker struct {
+	mu      sync.Mutex
+	pending map[string][]delivery
+	joining map[string]bool // channels whose JOIN USERSTATE is still due
+	now     func() time.Time
+	timeout time.Duration
+}
+
+func newTracker() *tracker {
+	return &tracker{
+		pending: make(map[string][]delivery),
+		joining: make(map[string]bool),
+		now:     time.Now,
+		timeout: ackTimeout,
+	}
+}
+
+// joined records that the client joined channel, so that the USERSTATE
+// following the JOIN isn't mistaken for an acknowledgement.
+func (t *tracker) joined(channel string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.joining[channel] = true
+}
+
+// sent records a message to channel awaiting acknowledgement.
+func (t *tracker) sent(channel string, result *Result) {
+	t.push(channel, delivery{result: result})
+}
+
+// sentCommand records a chat command to channel.
+func (t *tracker) sentCommand(channel string) {
+	t.push(channel, delivery{command: true})
+}
+
+func (t *tracker) push(channel string, d delivery) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	d.sent = t.now()
+	t.expire(channel, d.sent)
+	// Expire it even if nothing else happens in the channel.
+	d.timer = time.AfterFunc(t.timeout, func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.expire(channel, t.now())
+	})
+	t.pending[channel] = append(t.pending[channel], d)
+}
+
+// received resolves the oldest pending message if p answers it.
+func (t *tracker) received(p Packet) {
+	if len(p.Params) == 0 || len(p.Params[0]) < 2 {
+		return
+	}
+	var err error
+	switch p.Command {
+	case "USERSTATE":
+	case "NOTICE":
+		id := NoticeID(p.Tags["msg-id"])
+		if id.IsRejection() {
+			e := &NoticeError{Channel: p.Params[0][1:], MsgID: id}
+			if len(p.Params) > 1 {
+				e.Message = p.Params[1]
+			}
+			err = e
+		}
+	default:
+		return
+	}
+	channel := p.Params[0][1:]
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if p.Command == "USERSTATE" && t.joining[channel] {
+		delete(t.joining, channel)
+		return
+	}
+	t.expire(channel, t.now())
+	queue := t.pending[channel]
+	if p.Command == "USERSTATE" {
+		// The commands before the message got no NOTICE, or one that
+		// was already matched.
+		for len(queue) > 0 && queue[0].command {
+			queue[0].timer.Stop()
+			queue = queue[1:]
+		}
+	}
+	if len(queue) == 0 {
+		delete(t.pending, channel)
+		return
+	}
+	d := queue[0]
+	if p.Command == "NOTICE" && err == nil && !d.command {
+		// Any NOTICE answers a command, only rejections a message.
+		t.pending[channel] = queue
+		return
+	}
+	d.timer.Stop()
+	t.pending[channel] = queue[1:]
+	if d.result != nil {
+		d.result.ack(err)
+	}
+}
+
+// expire drops messages that have waited longer than the timeout.
+func (t *tracker) expire(channel string, now time.Time) {
+	queue := t.pending[channel]
+	i := 0
+	for i < len(queue) && now.Sub(queue[i].sent) >= t.timeout {
+		queue[i].timer.Stop()
+		if queue[i].result != nil {
+			queue[i].result.ack(ErrNoAck)
+		}
+		i++
+	}
+	if i == len(queue) {
+		delete(t.pending, channel)
+		return
+	}
+	t.pending[channel] = queue[i:]
+}
+
+// close fails every pending message with ErrClosed, once nothing more can
+// be received.
+func (t *tracker) close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for channel, queue := range t.pending {
+		for _, d := range queue {
+			d.timer.Stop()
+			if d.result != nil {
+				d.result.ack(ErrClosed)
+			}
+		}
+		delete(t.pending, channel)
+	}
+	for channel := range t.joining {
+		delete(t.joining, channel)
+	}
+}
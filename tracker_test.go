@@ -0,0 +1,124 @@
+package tmi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeliver(t *testing.T) {
+	c, _ := NewClient()
+	server, r := pipeClient(t, c)
+	go func() {
+		for range c.Events() {
+		}
+	}()
+	go func() {
+		replies := []string{
+			"@badge-info=;badges=;color=;display-name=bot;emote-sets=0;mod=0;subscriber=0;user-type= :tmi.twitch.tv USERSTATE #nymn\r\n",
+			"@msg-id=msg_duplicate :tmi.twitch.tv NOTICE #nymn :Your message is identical to the one you sent less than 30 seconds ago.\r\n",
+		}
+		for _, reply := range replies {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+			server.Write([]byte(reply))
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := c.Deliver(ctx, Say("nymn", "hi")); err != nil {
+		t.Fatalf("Deliver() = %v", err)
+	}
+	err := c.Deliver(ctx, Say("nymn", "hi"))
+	var notice *NoticeError
+	if !errors.As(err, &notice) || notice.MsgID != "msg_duplicate" || notice.Channel != "nymn" {
+		t.Fatalf("Deliver() = %v, want msg_duplicate", err)
+	}
+}
+
+func TestTrackerExpire(t *testing.T) {
+	now := time.Unix(0, 0)
+	tr := newTracker()
+	tr.now = func() time.Time { return now }
+
+	lost, acked := newResult(), newResult()
+	lost.expect(1)
+	acked.expect(1)
+	tr.sent("nymn", lost)
+	now = now.Add(ackTimeout)
+	tr.sent("nymn", acked)
+	p, _ := parsePacket([]byte(":tmi.twitch.tv USERSTATE #nymn"))
+	tr.received(p)
+
+	ctx := context.Background()
+	if err := lost.Ack(ctx); err != ErrNoAck {
+		t.Errorf("lost.Ack() = %v, want %v", err, ErrNoAck)
+	}
+	if err := acked.Ack(ctx); err != nil {
+		t.Errorf("acked.Ack() = %v", err)
+	}
+}
+
+func TestTrackerTimeout(t *testing.T) {
+	tr := newTracker()
+	tr.timeout = 10 * time.Millisecond
+	lost := newResult()
+	lost.expect(1)
+	tr.sent("nymn", lost)
+
+	// Nothing else is sent or received in the channel.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := lost.Ack(ctx); err != ErrNoAck {
+		t.Errorf("Ack() = %v, want %v", err, ErrNoAck)
+	}
+}
+
+func TestTrackerChatCommand(t *testing.T) {
+	tr := newTracker()
+	result := newResult()
+	result.expect(1)
+	tr.sentCommand("nymn")
+	tr.sent("nymn", result)
+
+	// The rejection answers the command, not the message after it.
+	p, _ := parsePacket([]byte("@msg-id=msg_ratelimit :tmi.twitch.tv NOTICE #nymn :Your message was not sent because you are sending messages too quickly."))
+	tr.received(p)
+	select {
+	case <-result.acked:
+		t.Fatal("message acknowledged by the command's NOTICE")
+	default:
+	}
+	p, _ = parsePacket([]byte(":tmi.twitch.tv USERSTATE #nymn"))
+	tr.received(p)
+	if err := result.Ack(context.Background()); err != nil {
+		t.Errorf("Ack() = %v", err)
+	}
+}
+
+func TestDeliverDisconnected(t *testing.T) {
+	c, _ := NewClient()
+	server, r := pipeClient(t, c)
+	go func() {
+		for {
+			if _, err := r.ReadString('\n'); err != nil {
+				return
+			}
+		}
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	result := c.Submit(Say("nymn", "hi"))
+	if err := result.Wait(ctx); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	if err := result.Ack(ctx); err != ErrClosed {
+		t.Errorf("Ack() after the connection dropped = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,114 @@
+package tmi
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// wsServer is a WebSocket server that pings each client and sends it the
+// lines given to it, one message per slice, and reports the messages it
+// receives.
+func wsServer(t *testing.T, send [][]string, received chan<- string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Upgrade") != "websocket" {
+			http.Error(w, "not a websocket request", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n")
+		rw.WriteString("Upgrade: websocket\r\nConnection: Upgrade\r\n")
+		rw.WriteString("Sec-WebSocket-Accept: " + acceptKey(r.Header.Get("Sec-WebSocket-Key")) + "\r\n\r\n")
+		rw.Flush()
+
+		writeFrame(conn, opPing, []byte("ping"), false)
+		for _, lines := range send {
+			writeFrame(conn, opText, []byte(strings.Join(lines, "\r\n")+"\r\n"), false)
+		}
+		for {
+			_, op, payload, err := readFrame(rw)
+			if err != nil || op == opClose {
+				return
+			}
+			if op == opPong {
+				payload = append([]byte("pong "), payload...)
+			}
+			received <- string(payload)
+		}
+	}))
+}
+
+func TestWebSocket(t *testing.T) {
+	received := make(chan string, 10)
+	s := wsServer(t, [][]string{
+		{
+			":tmi.twitch.tv 001 justinfan77777 :Welcome, GLHF!",
+			":foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :one",
+		},
+		{":foo!foo@foo.tmi.twitch.tv PRIVMSG #bar :two"},
+	}, received)
+	defer s.Close()
+
+	// Options apply in any order.
+	var dialed bool
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dialed = true
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	c, _ := NewClient(Address("ws"+strings.TrimPrefix(s.URL, "http")), WebSocket, Dialer(dial))
+	if err := c.Connect(); err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if !dialed {
+		t.Error("Dialer not used")
+	}
+
+	var handshake []string
+	for len(handshake) < 4 {
+		select {
+		case got := <-received:
+			handshake = append(handshake, got)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for handshake, got %q", handshake)
+		}
+	}
+	sort.Strings(handshake)
+	for i, want := range []string{"CAP REQ :", "NICK justinfan77777\r\n", "PASS ", "pong ping"} {
+		if !strings.HasPrefix(handshake[i], want) {
+			t.Errorf("server got %q, want %q...", handshake[i], want)
+		}
+	}
+
+	var messages []string
+	for ev := range c.Events() {
+		if msg, ok := ev.(PRIVMSG); ok {
+			messages = append(messages, msg.Message())
+			if len(messages) == 2 {
+				break
+			}
+		}
+	}
+	if strings.Join(messages, ",") != "one,two" {
+		t.Errorf("got messages %q", messages)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := c.Send(ctx, Join("bar")); err != nil {
+		t.Fatal(err)
+	}
+	if got := <-received; got != "JOIN #bar\r\n" {
+		t.Errorf("server got %q", got)
+	}
+}